@@ -2,6 +2,7 @@ package awsutils
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"gopkg.in/ini.v1"
@@ -17,9 +18,14 @@ func GetAwsProfiles() ([]string, error) {
 	var profiles []string
 	for _, section := range f.Sections() {
 		name := section.Name()
+		if len(section.Keys()) == 0 || strings.HasPrefix(name, "sso-session ") || strings.HasPrefix(name, "services ") {
+			// The implicit DEFAULT section is always present, even without a default profile, and
+			// sso-session and services sections are not profiles
+			continue
+		}
 		if name == "DEFAULT" {
 			profiles = append(profiles, "default")
-		} else if len(section.Keys()) > 0 {
+		} else {
 			const prefix = "profile "
 			if len(name) > len(prefix) && name[:len(prefix)] == prefix {
 				profiles = append(profiles, name[len(prefix):])
@@ -30,3 +36,129 @@ func GetAwsProfiles() ([]string, error) {
 	}
 	return profiles, nil
 }
+
+// GetProfileConfig returns the keys of the given profile from the AWS config file
+func GetProfileConfig(profile string) (map[string]string, error) {
+	fname := config.DefaultSharedConfigFilename()
+	f, err := ini.Load(fname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config file: %v", err)
+	}
+
+	sectionName := "profile " + profile
+	if profile == "default" && !f.HasSection(sectionName) {
+		sectionName = "default"
+	}
+	section, err := f.GetSection(sectionName)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s not found in AWS config file", profile)
+	}
+	return section.KeysHash(), nil
+}
+
+// GetProfileSSOSettings returns the SSO start URL, region and session name of a profile,
+// resolving profiles that reference an sso-session section
+func GetProfileSSOSettings(profile string) (startURL, region, sessionName string, err error) {
+	profileConfig, err := GetProfileConfig(profile)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	sessionName = profileConfig["sso_session"]
+	if sessionName == "" {
+		return profileConfig["sso_start_url"], profileConfig["sso_region"], "", nil
+	}
+
+	f, err := ini.Load(config.DefaultSharedConfigFilename())
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to load AWS config file: %v", err)
+	}
+	section, err := f.GetSection("sso-session " + sessionName)
+	if err != nil {
+		return "", "", "", fmt.Errorf("sso-session %s referenced by profile %s not found", sessionName, profile)
+	}
+	return section.Key("sso_start_url").String(), section.Key("sso_region").String(), sessionName, nil
+}
+
+// ProfileKind is how a profile gets its credentials
+type ProfileKind string
+
+const (
+	ProfileKindSSO         ProfileKind = "sso"
+	ProfileKindAssumeRole  ProfileKind = "assume-role"
+	ProfileKindWebIdentity ProfileKind = "web-identity"
+	ProfileKindProcess     ProfileKind = "credential_process"
+	ProfileKindStatic      ProfileKind = "static"
+)
+
+// ProfileLink is a profile in a chain of assumed roles
+type ProfileLink struct {
+	Name      string
+	Kind      ProfileKind
+	RoleArn   string
+	MFASerial string
+}
+
+// GetProfileKind returns how a profile with the given keys gets its credentials, in the order of
+// precedence the AWS SDKs use
+func GetProfileKind(profileConfig map[string]string) ProfileKind {
+	switch {
+	case profileConfig["role_arn"] != "" && profileConfig["web_identity_token_file"] != "":
+		return ProfileKindWebIdentity
+	case profileConfig["role_arn"] != "":
+		return ProfileKindAssumeRole
+	case profileConfig["sso_session"] != "" || profileConfig["sso_start_url"] != "":
+		return ProfileKindSSO
+	case profileConfig["credential_process"] != "":
+		return ProfileKindProcess
+	}
+	return ProfileKindStatic
+}
+
+// GetProfileChain follows source_profile from the profile to the profile providing the root
+// credentials, which is the last link. Profiles only found in the credentials file are static.
+func GetProfileChain(profile string) ([]ProfileLink, error) {
+	var chain []ProfileLink
+	seen := make(map[string]bool)
+	for {
+		if seen[profile] {
+			return nil, fmt.Errorf("source_profile of %s forms a cycle", chain[0].Name)
+		}
+		seen[profile] = true
+
+		profileConfig, err := GetProfileConfig(profile)
+		if err != nil {
+			if hasStaticCredentials(profile) {
+				return append(chain, ProfileLink{Name: profile, Kind: ProfileKindStatic}), nil
+			}
+			return nil, err
+		}
+
+		link := ProfileLink{
+			Name:      profile,
+			Kind:      GetProfileKind(profileConfig),
+			RoleArn:   profileConfig["role_arn"],
+			MFASerial: profileConfig["mfa_serial"],
+		}
+		chain = append(chain, link)
+
+		source := profileConfig["source_profile"]
+		if link.Kind != ProfileKindAssumeRole || source == "" {
+			return chain, nil
+		}
+		if source == profile {
+			// A role profile may use its own static keys as source credentials
+			return append(chain, ProfileLink{Name: profile, Kind: ProfileKindStatic}), nil
+		}
+		profile = source
+	}
+}
+
+// hasStaticCredentials reports whether the credentials file has a section for the profile
+func hasStaticCredentials(profile string) bool {
+	f, err := ini.Load(config.DefaultSharedCredentialsFilename())
+	if err != nil {
+		return false
+	}
+	return f.HasSection(profile)
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/spf13/cobra"
+)
+
+var accessProfile string
+
+// accessChecker collects what the EKS API knows about the access of the caller, or of the given
+// role when kubectl assumes one, to a cluster - can be mocked in tests
+var accessChecker = checkClusterAccess
+
+// accessStatus is the verdict of an access check
+type accessStatus string
+
+const (
+	accessGranted accessStatus = "granted"
+	accessMissing accessStatus = "missing"
+	accessUnknown accessStatus = "unknown"
+)
+
+// accessPolicy is an access policy associated with an access entry
+type accessPolicy struct {
+	PolicyArn  string
+	Scope      string
+	Namespaces []string
+}
+
+// accessReport describes the access of the caller of a profile to a cluster
+type accessReport struct {
+	Cluster            string
+	Profile            string
+	CallerArn          string
+	PrincipalArn       string
+	AuthenticationMode string
+	EntriesError       string
+	EntryFound         bool
+	EntryPrincipalArn  string
+	EntryType          string
+	Username           string
+	KubernetesGroups   []string
+	Policies           []accessPolicy
+	PoliciesError      string
+}
+
+var accessCmd = &cobra.Command{
+	Use:   "access [cluster]",
+	Short: "Check whether the current role has access to an EKS cluster",
+	Long: `Check the authentication mode of the cluster and the EKS access entry and associated access
+policies of the caller's role, and explain what is missing when kubectl answers "Unauthorized".
+
+Without a cluster, the cluster and profile of the current kubeconfig context are checked. The
+profile defaults to AWS_PROFILE and can be set with --profile.
+
+Access granted through the aws-auth ConfigMap can't be read through the EKS API and is reported
+as unknown. Exits with status 1 when access is missing.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		out := cmd.OutOrStdout()
+
+		profile, region, cluster, roleArn := accessProfile, "", "", ""
+		if len(args) == 1 {
+			cluster = args[0]
+		} else {
+			kubeConfig, err := loadKubeConfig()
+			if err != nil || kubeConfig.CurrentContext == "" || kubeConfig.Contexts[kubeConfig.CurrentContext] == nil {
+				fmt.Fprintln(out, "No cluster given and no current kubeconfig context")
+				return
+			}
+			var ok bool
+			region, _, cluster, ok = parseClusterArn(kubeConfig.Contexts[kubeConfig.CurrentContext].Cluster)
+			if !ok {
+				fmt.Fprintf(out, "Current context %s is not an EKS cluster\n", kubeConfig.CurrentContext)
+				return
+			}
+			if profile == "" {
+				profile = contextProfile(kubeConfig, kubeConfig.CurrentContext)
+			}
+			roleArn = contextRoleArn(kubeConfig, kubeConfig.CurrentContext)
+		}
+		if profile == "" {
+			profile = os.Getenv("AWS_PROFILE")
+		}
+		if profile == "" {
+			profile = "default"
+		}
+		if region == "" {
+			var err error
+			if region, err = clusterProvider.GetRegion(ctx, profile); err != nil {
+				fmt.Fprintf(out, "Failed to get region for profile %s: %v\n", profile, err)
+				return
+			}
+		}
+
+		report, err := accessChecker(ctx, profile, region, cluster, roleArn)
+		if err != nil {
+			fmt.Fprintln(out, "Access check failed:", err)
+			os.Exit(1)
+		}
+		if status, _ := printAccessReport(out, report); status == accessMissing {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(accessCmd)
+	accessCmd.Flags().StringVar(&accessProfile, "profile", "", "Profile to check the access of (default: the context's profile or AWS_PROFILE)")
+}
+
+// verifyClusterAccess runs the access check after 'use --verify', for the role kubectl assumes
+// when the token args have one
+func verifyClusterAccess(ctx context.Context, profile string, clusterInfo *ClusterInfo) {
+	report, err := accessChecker(ctx, profile, clusterInfo.Region, clusterInfo.Name, tokenRoleArn(clusterInfo))
+	if err != nil {
+		fmt.Fprintf(outputWriter, "Failed to verify cluster access: %v\n", err)
+		return
+	}
+	printAccessReport(outputWriter, report)
+}
+
+// Evaluate decides whether the report shows access to the cluster and explains why
+func (r *accessReport) Evaluate() (accessStatus, []string) {
+	configMapNote := "Access may still be granted by the aws-auth ConfigMap, which can't be read through the EKS API. " +
+		"Ask a cluster admin to check its mapRoles for " + r.PrincipalArn + "."
+	createHint := fmt.Sprintf("Ask a cluster admin to grant access, e.g.:\n"+
+		"  aws eks create-access-entry --cluster-name %s --principal-arn %s\n"+
+		"  aws eks associate-access-policy --cluster-name %s --principal-arn %s \\\n"+
+		"    --policy-arn arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy --access-scope type=cluster\n"+
+		"(for IAM Identity Center roles use the role ARN including its aws-reserved/sso.amazonaws.com/ path)",
+		r.Cluster, r.PrincipalArn, r.Cluster, r.PrincipalArn)
+
+	switch {
+	case r.AuthenticationMode == string(types.AuthenticationModeConfigMap):
+		return accessUnknown, []string{
+			"The cluster only uses the aws-auth ConfigMap, which can't be read through the EKS API.",
+			"Ask a cluster admin to map " + r.PrincipalArn + " in aws-auth (mapRoles, role ARN without path), " +
+				"or to switch the authentication mode to API_AND_CONFIG_MAP and create an access entry.",
+		}
+	case r.EntriesError != "":
+		return accessUnknown, []string{
+			"Could not read the access entries: " + r.EntriesError,
+			"The role may lack eks:ListAccessEntries, which doesn't mean it has no access to the cluster.",
+		}
+	case !r.EntryFound && r.AuthenticationMode == string(types.AuthenticationModeApiAndConfigMap):
+		return accessUnknown, []string{"No access entry for " + r.PrincipalArn + ".", configMapNote, createHint}
+	case !r.EntryFound:
+		return accessMissing, []string{"No access entry for " + r.PrincipalArn + ".", createHint}
+	case r.EntryType != "" && r.EntryType != "STANDARD":
+		return accessMissing, []string{fmt.Sprintf("The access entry has type %s, which is meant for nodes, not users.", r.EntryType)}
+	case r.PoliciesError != "":
+		return accessUnknown, []string{
+			"Could not read the access policies of the access entry: " + r.PoliciesError,
+			"The role may lack eks:ListAssociatedAccessPolicies, which doesn't mean it has no access to the cluster.",
+		}
+	case len(r.Policies) == 0 && len(r.KubernetesGroups) == 0:
+		return accessMissing, []string{
+			"The access entry has no access policies and no Kubernetes groups, so it grants no permissions.",
+			fmt.Sprintf("Ask a cluster admin to associate an access policy, e.g.:\n"+
+				"  aws eks associate-access-policy --cluster-name %s --principal-arn %s \\\n"+
+				"    --policy-arn arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy --access-scope type=cluster",
+				r.Cluster, r.EntryPrincipalArn),
+		}
+	case len(r.Policies) == 0:
+		return accessGranted, []string{"Permissions come from RBAC bindings for the Kubernetes groups " +
+			strings.Join(r.KubernetesGroups, ", ") + ", which can't be checked through the EKS API."}
+	}
+	return accessGranted, nil
+}
+
+// printAccessReport prints the report with its verdict and returns the verdict
+func printAccessReport(w io.Writer, r *accessReport) (accessStatus, []string) {
+	fmt.Fprintf(w, "Cluster:             %s\n", r.Cluster)
+	fmt.Fprintf(w, "Profile:             %s\n", r.Profile)
+	fmt.Fprintf(w, "Caller:              %s\n", r.CallerArn)
+	if callerPrincipal, _, _ := principalFromCallerArn(r.CallerArn); r.PrincipalArn != callerPrincipal {
+		fmt.Fprintf(w, "Checked principal:   %s (assumed by kubectl)\n", r.PrincipalArn)
+	}
+	fmt.Fprintf(w, "Authentication mode: %s\n", r.AuthenticationMode)
+	if r.EntryFound {
+		fmt.Fprintf(w, "Access entry:        %s (%s)\n", r.EntryPrincipalArn, r.EntryType)
+		if r.Username != "" {
+			fmt.Fprintf(w, "Kubernetes user:     %s\n", r.Username)
+		}
+		if len(r.KubernetesGroups) > 0 {
+			fmt.Fprintf(w, "Kubernetes groups:   %s\n", strings.Join(r.KubernetesGroups, ", "))
+		}
+		if len(r.Policies) > 0 {
+			fmt.Fprintln(w, "Access policies:")
+			for _, policy := range r.Policies {
+				name := policy.PolicyArn[strings.LastIndex(policy.PolicyArn, "/")+1:]
+				if policy.Scope == string(types.AccessScopeTypeNamespace) {
+					fmt.Fprintf(w, "  %s (namespaces: %s)\n", name, strings.Join(policy.Namespaces, ", "))
+				} else {
+					fmt.Fprintf(w, "  %s (cluster)\n", name)
+				}
+			}
+		}
+	}
+
+	status, notes := r.Evaluate()
+	fmt.Fprintf(w, "Access:              %s\n", status)
+	for _, note := range notes {
+		fmt.Fprintln(w, note)
+	}
+	return status, notes
+}
+
+// principalFromCallerArn turns an STS caller ARN into the IAM principal ARN access entries use.
+// Assumed roles are returned without path since STS doesn't report it.
+func principalFromCallerArn(callerArn string) (principalArn, accountID, roleName string) {
+	parts := strings.SplitN(callerArn, ":", 6)
+	if len(parts) != 6 {
+		return callerArn, "", ""
+	}
+	accountID = parts[4]
+	if resource, ok := strings.CutPrefix(parts[5], "assumed-role/"); ok {
+		roleName, _, _ = strings.Cut(resource, "/")
+		return fmt.Sprintf("arn:%s:iam::%s:role/%s", parts[1], accountID, roleName), accountID, roleName
+	}
+	return callerArn, accountID, ""
+}
+
+// roleFromPrincipalArn returns the account and role name of an IAM role ARN, which may have a path
+func roleFromPrincipalArn(principalArn string) (accountID, roleName string) {
+	parts := strings.SplitN(principalArn, ":", 6)
+	if len(parts) != 6 {
+		return "", ""
+	}
+	resource, ok := strings.CutPrefix(parts[5], "role/")
+	if !ok {
+		return parts[4], ""
+	}
+	return parts[4], resource[strings.LastIndex(resource, "/")+1:]
+}
+
+// principalMatches reports whether an access entry principal is the caller's principal. Roles
+// are compared by account and name because access entries may include the role's path.
+func principalMatches(entryArn, principalArn, accountID, roleName string) bool {
+	if entryArn == principalArn {
+		return true
+	}
+	if roleName == "" {
+		return false
+	}
+	parts := strings.SplitN(entryArn, ":", 6)
+	if len(parts) != 6 || parts[4] != accountID || !strings.HasPrefix(parts[5], "role/") {
+		return false
+	}
+	return parts[5][strings.LastIndex(parts[5], "/")+1:] == roleName
+}
+
+// accessEKSAPI is the part of the EKS API used to check access to a cluster
+type accessEKSAPI interface {
+	DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error)
+	ListAccessEntries(ctx context.Context, params *eks.ListAccessEntriesInput, optFns ...func(*eks.Options)) (*eks.ListAccessEntriesOutput, error)
+	DescribeAccessEntry(ctx context.Context, params *eks.DescribeAccessEntryInput, optFns ...func(*eks.Options)) (*eks.DescribeAccessEntryOutput, error)
+	ListAssociatedAccessPolicies(ctx context.Context, params *eks.ListAssociatedAccessPoliciesInput, optFns ...func(*eks.Options)) (*eks.ListAssociatedAccessPoliciesOutput, error)
+}
+
+// checkClusterAccess looks up the caller, the cluster's authentication mode and the access entry
+// and associated access policies of the caller, or of roleArn when it is set. The EKS API is
+// always called with the profile's credentials.
+func checkClusterAccess(ctx context.Context, profile, region, clusterName, roleArn string) (*accessReport, error) {
+	cfg, err := loadAWSConfig(ctx, profile, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	report := &accessReport{Cluster: clusterName, Profile: profile}
+
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	report.CallerArn = aws.ToString(identity.Arn)
+	report.PrincipalArn, _, _ = principalFromCallerArn(report.CallerArn)
+	if roleArn != "" {
+		report.PrincipalArn = roleArn
+	}
+
+	if err := collectClusterAccess(ctx, eks.NewFromConfig(cfg), report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// collectClusterAccess fills in the authentication mode of the report's cluster and the access
+// entry and associated access policies of its principal. Missing permissions to read the access
+// entries or policies are kept in the report, since they don't tell whether the caller has access.
+func collectClusterAccess(ctx context.Context, eksClient accessEKSAPI, report *accessReport) error {
+	clusterName := report.Cluster
+	accountID, roleName := roleFromPrincipalArn(report.PrincipalArn)
+
+	cluster, err := eksClient.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(clusterName)})
+	if err != nil {
+		return fmt.Errorf("failed to describe EKS cluster: %w", err)
+	}
+	report.AuthenticationMode = string(types.AuthenticationModeConfigMap)
+	if accessConfig := cluster.Cluster.AccessConfig; accessConfig != nil && accessConfig.AuthenticationMode != "" {
+		report.AuthenticationMode = string(accessConfig.AuthenticationMode)
+	}
+	if report.AuthenticationMode == string(types.AuthenticationModeConfigMap) {
+		return nil
+	}
+
+	paginator := eks.NewListAccessEntriesPaginator(eksClient, &eks.ListAccessEntriesInput{ClusterName: aws.String(clusterName)})
+	for paginator.HasMorePages() && !report.EntryFound {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			report.EntriesError = err.Error()
+			return nil
+		}
+		for _, entryArn := range page.AccessEntries {
+			if principalMatches(entryArn, report.PrincipalArn, accountID, roleName) {
+				report.EntryFound = true
+				report.EntryPrincipalArn = entryArn
+				break
+			}
+		}
+	}
+	if !report.EntryFound {
+		return nil
+	}
+
+	entry, err := eksClient.DescribeAccessEntry(ctx, &eks.DescribeAccessEntryInput{
+		ClusterName:  aws.String(clusterName),
+		PrincipalArn: aws.String(report.EntryPrincipalArn),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe access entry: %w", err)
+	}
+	report.EntryType = aws.ToString(entry.AccessEntry.Type)
+	report.Username = aws.ToString(entry.AccessEntry.Username)
+	report.KubernetesGroups = entry.AccessEntry.KubernetesGroups
+
+	policies := eks.NewListAssociatedAccessPoliciesPaginator(eksClient, &eks.ListAssociatedAccessPoliciesInput{
+		ClusterName:  aws.String(clusterName),
+		PrincipalArn: aws.String(report.EntryPrincipalArn),
+	})
+	for policies.HasMorePages() {
+		page, err := policies.NextPage(ctx)
+		var accessDenied *types.AccessDeniedException
+		if errors.As(err, &accessDenied) {
+			report.Policies = nil
+			report.PoliciesError = err.Error()
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list associated access policies: %w", err)
+		}
+		for _, policy := range page.AssociatedAccessPolicies {
+			p := accessPolicy{PolicyArn: aws.ToString(policy.PolicyArn)}
+			if policy.AccessScope != nil {
+				p.Scope = string(policy.AccessScope.Type)
+				p.Namespaces = policy.AccessScope.Namespaces
+			}
+			report.Policies = append(report.Policies, p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestPrincipalFromCallerArn(t *testing.T) {
+	principal, account, role := principalFromCallerArn("arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Operator_abc123/jane@example.com")
+	if principal != "arn:aws:iam::123456789012:role/AWSReservedSSO_Operator_abc123" || account != "123456789012" || role != "AWSReservedSSO_Operator_abc123" {
+		t.Errorf("Unexpected principal %q, account %q, role %q", principal, account, role)
+	}
+
+	principal, _, role = principalFromCallerArn("arn:aws:iam::123456789012:user/jane")
+	if principal != "arn:aws:iam::123456789012:user/jane" || role != "" {
+		t.Errorf("Expected IAM user ARN to be kept, got %q (role %q)", principal, role)
+	}
+}
+
+func TestPrincipalMatches(t *testing.T) {
+	principal := "arn:aws:iam::123456789012:role/AWSReservedSSO_Operator_abc123"
+	tests := []struct {
+		entry string
+		want  bool
+	}{
+		{principal, true},
+		{"arn:aws:iam::123456789012:role/aws-reserved/sso.amazonaws.com/eu-west-1/AWSReservedSSO_Operator_abc123", true},
+		{"arn:aws:iam::999999999999:role/AWSReservedSSO_Operator_abc123", false},
+		{"arn:aws:iam::123456789012:role/AWSReservedSSO_ReadOnly_def456", false},
+		{"arn:aws:iam::123456789012:user/AWSReservedSSO_Operator_abc123", false},
+	}
+	for _, tt := range tests {
+		if got := principalMatches(tt.entry, principal, "123456789012", "AWSReservedSSO_Operator_abc123"); got != tt.want {
+			t.Errorf("principalMatches(%q) = %v, want %v", tt.entry, got, tt.want)
+		}
+	}
+}
+
+func TestAccessReportEvaluate(t *testing.T) {
+	principal := "arn:aws:iam::123456789012:role/Operator"
+	tests := []struct {
+		name   string
+		report accessReport
+		want   accessStatus
+		note   string
+	}{
+		{"config map only", accessReport{AuthenticationMode: "CONFIG_MAP"}, accessUnknown, "aws-auth"},
+		{"entries not readable", accessReport{AuthenticationMode: "API", EntriesError: "AccessDenied"}, accessUnknown, "eks:ListAccessEntries"},
+		{"no entry in API mode", accessReport{AuthenticationMode: "API"}, accessMissing, "create-access-entry"},
+		{"no entry in mixed mode", accessReport{AuthenticationMode: "API_AND_CONFIG_MAP"}, accessUnknown, "aws-auth ConfigMap"},
+		{"node entry", accessReport{AuthenticationMode: "API", EntryFound: true, EntryType: "EC2_LINUX"}, accessMissing, "nodes"},
+		{"empty entry", accessReport{AuthenticationMode: "API", EntryFound: true, EntryType: "STANDARD"}, accessMissing, "associate-access-policy"},
+		{"policies not readable", accessReport{AuthenticationMode: "API", EntryFound: true, EntryType: "STANDARD", PoliciesError: "AccessDeniedException"}, accessUnknown, "eks:ListAssociatedAccessPolicies"},
+		{"groups only", accessReport{AuthenticationMode: "API", EntryFound: true, EntryType: "STANDARD", KubernetesGroups: []string{"viewers"}}, accessGranted, "viewers"},
+		{"policy", accessReport{AuthenticationMode: "API", EntryFound: true, EntryType: "STANDARD",
+			Policies: []accessPolicy{{PolicyArn: "arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy", Scope: "cluster"}}}, accessGranted, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.report.Cluster = "payments-eks-1"
+			tt.report.PrincipalArn = principal
+			status, notes := tt.report.Evaluate()
+			if status != tt.want {
+				t.Errorf("Expected %s, got %s (%v)", tt.want, status, notes)
+			}
+			if tt.note != "" && !strings.Contains(strings.Join(notes, "\n"), tt.note) {
+				t.Errorf("Expected notes to mention %q, got %v", tt.note, notes)
+			}
+		})
+	}
+}
+
+func TestUseVerifyPrintsAccess(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ASP_EKS_HOME", "")
+
+	originalProvider, originalChecker := clusterProvider, accessChecker
+	defer func() { clusterProvider, accessChecker = originalProvider, originalChecker }()
+	clusterProvider = &mockClusterProvider{region: "eu-west-1"}
+
+	var checked string
+	accessChecker = func(ctx context.Context, profile, region, clusterName, roleArn string) (*accessReport, error) {
+		checked = profile + "/" + region + "/" + clusterName + "/" + roleArn
+		principalArn := "arn:aws:iam::123456789012:role/Operator"
+		if roleArn != "" {
+			principalArn = roleArn
+		}
+		return &accessReport{
+			Cluster:            clusterName,
+			Profile:            profile,
+			CallerArn:          "arn:aws:sts::123456789012:assumed-role/Operator/jane",
+			PrincipalArn:       principalArn,
+			AuthenticationMode: "API",
+			EntryFound:         true,
+			EntryPrincipalArn:  "arn:aws:iam::123456789012:role/Operator",
+			EntryType:          "STANDARD",
+			Policies:           []accessPolicy{{PolicyArn: "arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy", Scope: "namespace", Namespaces: []string{"payments"}}},
+		}, nil
+	}
+
+	var output bytes.Buffer
+	outputWriter = &output
+	defer func() { outputWriter = os.Stdout }()
+	verifyFlag = true
+	defer func() { verifyFlag = false }()
+
+	updateKubeconfig("payments-prod-operator", "payments-eks-1", false)
+
+	if checked != "payments-prod-operator/eu-west-1/payments-eks-1/" {
+		t.Errorf("Expected access check for the switched cluster, got %q", checked)
+	}
+	out := output.String()
+	for _, want := range []string{"AmazonEKSViewPolicy (namespaces: payments)", "Access:              granted"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Checked principal") {
+		t.Errorf("Expected no separate principal without a role override, got:\n%s", out)
+	}
+
+	// With a role override the role kubectl assumes is checked, not the profile's own role
+	roleArnFlag = "arn:aws:iam::123456789012:role/cluster-admin"
+	defer func() { roleArnFlag = "" }()
+	output.Reset()
+	updateKubeconfig("payments-prod-operator", "payments-eks-1", false)
+
+	if checked != "payments-prod-operator/eu-west-1/payments-eks-1/arn:aws:iam::123456789012:role/cluster-admin" {
+		t.Errorf("Expected the access of the override role to be checked, got %q", checked)
+	}
+	if !strings.Contains(output.String(), "Checked principal:   arn:aws:iam::123456789012:role/cluster-admin (assumed by kubectl)") {
+		t.Errorf("Expected the checked role in the output, got:\n%s", output.String())
+	}
+}
+
+// fakeAccessEKS has an access entry for the Operator role but denies listing its access policies
+type fakeAccessEKS struct{}
+
+func (fakeAccessEKS) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	return &eks.DescribeClusterOutput{Cluster: &types.Cluster{
+		Name:         params.Name,
+		AccessConfig: &types.AccessConfigResponse{AuthenticationMode: types.AuthenticationModeApi},
+	}}, nil
+}
+
+func (fakeAccessEKS) ListAccessEntries(ctx context.Context, params *eks.ListAccessEntriesInput, optFns ...func(*eks.Options)) (*eks.ListAccessEntriesOutput, error) {
+	return &eks.ListAccessEntriesOutput{AccessEntries: []string{"arn:aws:iam::123456789012:role/aws-reserved/sso.amazonaws.com/Operator"}}, nil
+}
+
+func (fakeAccessEKS) DescribeAccessEntry(ctx context.Context, params *eks.DescribeAccessEntryInput, optFns ...func(*eks.Options)) (*eks.DescribeAccessEntryOutput, error) {
+	return &eks.DescribeAccessEntryOutput{AccessEntry: &types.AccessEntry{PrincipalArn: params.PrincipalArn, Type: aws.String("STANDARD")}}, nil
+}
+
+func (fakeAccessEKS) ListAssociatedAccessPolicies(ctx context.Context, params *eks.ListAssociatedAccessPoliciesInput, optFns ...func(*eks.Options)) (*eks.ListAssociatedAccessPoliciesOutput, error) {
+	return nil, &types.AccessDeniedException{Message: aws.String("not authorized to perform eks:ListAssociatedAccessPolicies")}
+}
+
+func TestCollectClusterAccessPoliciesDenied(t *testing.T) {
+	report := &accessReport{
+		Cluster:      "payments-eks-1",
+		CallerArn:    "arn:aws:sts::123456789012:assumed-role/Operator/jane",
+		PrincipalArn: "arn:aws:iam::123456789012:role/Operator",
+	}
+	if err := collectClusterAccess(context.Background(), fakeAccessEKS{}, report); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !report.EntryFound || !strings.Contains(report.PoliciesError, "eks:ListAssociatedAccessPolicies") {
+		t.Errorf("Expected the access entry and a policies error, got %+v", report)
+	}
+	if status, notes := report.Evaluate(); status != accessUnknown {
+		t.Errorf("Expected unknown access when the policies can't be read, got %s (%v)", status, notes)
+	}
+}
@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/ini.v1"
+)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage short names for profiles and clusters",
+	Long: `Manage aliases stored in the [aliases] section of ~/.asp-eks/config. An alias points at a
+profile, or at a profile and cluster written as <profile>:<cluster>, and can be used everywhere
+a profile is accepted:
+
+  asp-eks alias set pay-prod payments-prod-operator:payments-eks-1
+  asp-eks use pay-prod`,
+}
+
+var aliasSetCmd = &cobra.Command{
+	Use:   "set <alias> <profile[:cluster]>",
+	Short: "Create or update an alias",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, target := args[0], args[1]
+		if name == "-" || strings.ContainsAny(name, ": \t=") {
+			fmt.Fprintf(cmd.OutOrStdout(), "Invalid alias name %q\n", name)
+			return
+		}
+		if profile, _ := splitTarget(target); profile == "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Invalid target %q, expected <profile> or <profile>:<cluster>\n", target)
+			return
+		}
+
+		settings, err := loadSettings()
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Error:", err)
+			return
+		}
+		settings.Section("aliases").Key(name).SetValue(target)
+		if err := saveSettings(settings); err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to save alias:", err)
+			return
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Alias %s -> %s\n", name, target)
+	},
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List aliases",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		settings, err := loadSettings()
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Error:", err)
+			return
+		}
+		aliases := loadAliases(settings)
+		if len(aliases) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No aliases defined")
+			return
+		}
+		for _, name := range sortedKeys(aliases) {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s -> %s\n", name, aliases[name])
+		}
+	},
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:   "remove <alias>",
+	Short: "Remove an alias",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		settings, err := loadSettings()
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Error:", err)
+			return
+		}
+		if !settings.HasSection("aliases") || !settings.Section("aliases").HasKey(args[0]) {
+			fmt.Fprintf(cmd.OutOrStdout(), "Alias %s does not exist\n", args[0])
+			return
+		}
+		settings.Section("aliases").DeleteKey(args[0])
+		if err := saveSettings(settings); err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to save settings:", err)
+			return
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed alias %s\n", args[0])
+	},
+}
+
+var favCmd = &cobra.Command{
+	Use:   "fav",
+	Short: "Manage favourite profiles and clusters",
+	Long: `Pin frequently used targets (profiles, <profile>:<cluster> or aliases) so they are shown first
+by 'list' and in the cluster picker of 'use'. Favourites are stored in ~/.asp-eks/favourites.`,
+}
+
+var favAddCmd = &cobra.Command{
+	Use:   "add <profile[:cluster]|alias>",
+	Short: "Add a favourite",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		favourites, err := loadFavourites()
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Error:", err)
+			return
+		}
+		for _, favourite := range favourites {
+			if favourite == args[0] {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s is already a favourite\n", args[0])
+				return
+			}
+		}
+		if err := saveFavourites(append(favourites, args[0])); err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to save favourites:", err)
+			return
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Added %s to favourites\n", args[0])
+	},
+}
+
+var favListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List favourites",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		favourites, err := loadFavourites()
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Error:", err)
+			return
+		}
+		if len(favourites) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No favourites defined")
+			return
+		}
+		settings, err := loadSettings()
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Error:", err)
+			return
+		}
+		printFavourites(cmd.OutOrStdout(), favourites, loadAliases(settings))
+	},
+}
+
+var favRemoveCmd = &cobra.Command{
+	Use:   "remove <profile[:cluster]|alias>",
+	Short: "Remove a favourite",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		favourites, err := loadFavourites()
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Error:", err)
+			return
+		}
+		var kept []string
+		for _, favourite := range favourites {
+			if favourite != args[0] {
+				kept = append(kept, favourite)
+			}
+		}
+		if len(kept) == len(favourites) {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is not a favourite\n", args[0])
+			return
+		}
+		if err := saveFavourites(kept); err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "Failed to save favourites:", err)
+			return
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %s from favourites\n", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasSetCmd, aliasListCmd, aliasRemoveCmd)
+	rootCmd.AddCommand(favCmd)
+	favCmd.AddCommand(favAddCmd, favListCmd, favRemoveCmd)
+}
+
+// splitTarget splits a target of 'use'. Targets are a profile, optionally with the cluster to
+// select, written as "<profile>:<cluster>"; the cluster is empty for a plain profile.
+func splitTarget(target string) (profile, cluster string) {
+	profile, cluster, _ = strings.Cut(target, ":")
+	return strings.TrimSpace(profile), strings.TrimSpace(cluster)
+}
+
+func loadAliases(settings *ini.File) map[string]string {
+	if !settings.HasSection("aliases") {
+		return nil
+	}
+	return settings.Section("aliases").KeysHash()
+}
+
+// resolveTarget returns the profile and cluster a target refers to, expanding aliases
+func resolveTarget(aliases map[string]string, target string) (profile, cluster string) {
+	if aliased, ok := aliases[target]; ok {
+		target = aliased
+	}
+	return splitTarget(target)
+}
+
+func getFavouritesPath() string {
+	dir := getSettingsDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "favourites")
+}
+
+// loadFavourites returns the favourite targets in the order they were added
+func loadFavourites() ([]string, error) {
+	favouritesPath := getFavouritesPath()
+	if favouritesPath == "" {
+		return nil, fmt.Errorf("could not determine favourites path")
+	}
+
+	data, err := os.ReadFile(favouritesPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read favourites file: %w", err)
+	}
+
+	var favourites []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			favourites = append(favourites, line)
+		}
+	}
+	return favourites, nil
+}
+
+func saveFavourites(favourites []string) error {
+	favouritesPath := getFavouritesPath()
+	if favouritesPath == "" {
+		return fmt.Errorf("could not determine favourites path")
+	}
+	if err := os.MkdirAll(filepath.Dir(favouritesPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(favouritesPath), err)
+	}
+
+	content := strings.Join(favourites, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return writeFileAtomically(favouritesPath, []byte(content))
+}
+
+func printFavourites(w io.Writer, favourites []string, aliases map[string]string) {
+	for _, favourite := range favourites {
+		if aliased, ok := aliases[favourite]; ok {
+			fmt.Fprintf(w, "%s -> %s\n", favourite, aliased)
+		} else {
+			fmt.Fprintln(w, favourite)
+		}
+	}
+}
+
+// pinFavourites moves the names for which isFavourite returns true to the front, keeping the
+// order of both groups. It returns the reordered names and the number of favourites.
+func pinFavourites(names []string, isFavourite func(name string) bool) ([]string, int) {
+	var pinned, others []string
+	for _, name := range names {
+		if isFavourite(name) {
+			pinned = append(pinned, name)
+		} else {
+			others = append(others, name)
+		}
+	}
+	return append(pinned, others...), len(pinned)
+}
+
+// favouriteTargets resolves the favourites to a set of profiles and a set of profile:cluster pairs
+func favouriteTargets(favourites []string, aliases map[string]string) (profiles, clusters map[string]bool) {
+	profiles = make(map[string]bool)
+	clusters = make(map[string]bool)
+	for _, favourite := range favourites {
+		profile, cluster := resolveTarget(aliases, favourite)
+		profiles[profile] = true
+		if cluster != "" {
+			clusters[profile+":"+cluster] = true
+		}
+	}
+	return profiles, clusters
+}
+
+// loadFavouriteTargets loads favourites and aliases, ignoring errors since favourites only
+// affect the order things are shown in
+func loadFavouriteTargets() (profiles, clusters map[string]bool) {
+	var aliases map[string]string
+	if settings, err := loadSettings(); err == nil {
+		aliases = loadAliases(settings)
+	}
+	favourites, _ := loadFavourites()
+	return favouriteTargets(favourites, aliases)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAliasCommands(t *testing.T) {
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+
+	var output bytes.Buffer
+	rootCmd.SetOut(&output)
+	for _, args := range [][]string{
+		{"alias", "set", "pay-prod", "payments-prod-operator:payments-eks-1"},
+		{"alias", "set", "pay-dev", "payments-dev-operator"},
+		{"alias", "remove", "pay-dev"},
+		{"alias", "list"},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Expected no error for %v, got %v", args, err)
+		}
+	}
+
+	if !strings.HasSuffix(output.String(), "Removed alias pay-dev\npay-prod -> payments-prod-operator:payments-eks-1\n") {
+		t.Errorf("Unexpected output: %s", output.String())
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	profile, cluster := resolveTarget(loadAliases(settings), "pay-prod")
+	if profile != "payments-prod-operator" || cluster != "payments-eks-1" {
+		t.Errorf("Expected alias to resolve to payments-prod-operator:payments-eks-1, got %s:%s", profile, cluster)
+	}
+	if profile, cluster := resolveTarget(loadAliases(settings), "shared-dev:tools"); profile != "shared-dev" || cluster != "tools" {
+		t.Errorf("Expected shared-dev:tools to be split, got %s:%s", profile, cluster)
+	}
+}
+
+func TestUseCommandWithAlias(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	os.WriteFile(getSettingsPath(), []byte("[aliases]\npay-prod = payments-prod-operator:payments-eks-1\n"), 0644)
+
+	originalProvider := clusterProvider
+	clusterProvider = &mockClusterProvider{region: "eu-west-1", shouldFailListClusters: true}
+	defer func() { clusterProvider = originalProvider }()
+
+	originalCredentialsValidator := credentialsValidator
+	var checkedProfile string
+	credentialsValidator = func(ctx context.Context, profile string) bool {
+		checkedProfile = profile
+		return true
+	}
+	defer func() { credentialsValidator = originalCredentialsValidator }()
+
+	var output bytes.Buffer
+	outputWriter = &output
+	defer func() { outputWriter = os.Stdout }()
+
+	rootCmd.SetArgs([]string{"use", "pay-prod"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if checkedProfile != "payments-prod-operator" {
+		t.Errorf("Expected credentials of payments-prod-operator to be checked, got %s", checkedProfile)
+	}
+	if !strings.Contains(output.String(), "Successfully updated kubeconfig for cluster: payments-eks-1") {
+		t.Errorf("Expected the aliased cluster to be used without listing clusters, got: %s", output.String())
+	}
+}
+
+func TestFavouritesArePinned(t *testing.T) {
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	os.WriteFile(getSettingsPath(), []byte("[aliases]\npay-prod = payments-prod-operator:payments-eks-1\n"), 0644)
+
+	var output bytes.Buffer
+	rootCmd.SetOut(&output)
+	for _, args := range [][]string{{"fav", "add", "pay-prod"}, {"fav", "add", "shared-dev"}, {"fav", "list"}} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("Expected no error for %v, got %v", args, err)
+		}
+	}
+	if !strings.HasSuffix(output.String(), "pay-prod -> payments-prod-operator:payments-eks-1\nshared-dev\n") {
+		t.Errorf("Unexpected fav list output: %s", output.String())
+	}
+
+	originalGetProfiles := getProfiles
+	getProfiles = func() ([]string, error) {
+		return []string{"other", "payments-prod-operator", "shared-dev", "zeta"}, nil
+	}
+	defer func() { getProfiles = originalGetProfiles }()
+
+	output.Reset()
+	rootCmd.SetArgs([]string{"list"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "Available profiles:\npayments-prod-operator ★\nshared-dev ★\nother\nzeta\n"
+	if output.String() != expected {
+		t.Errorf("Expected favourites first, got:\n%s", output.String())
+	}
+
+	_, clusters := loadFavouriteTargets()
+	if !clusters["payments-prod-operator:payments-eks-1"] {
+		t.Errorf("Expected the aliased cluster to be a favourite, got %v", clusters)
+	}
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/eimarfandino/asp-eks/awsutils"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+var cleanYes bool
+
+// clusterExistenceChecker reports whether an EKS cluster still exists - can be mocked in tests
+var clusterExistenceChecker = clusterExists
+
+// staleContext is a kubeconfig context created by asp-eks that should be removed
+type staleContext struct {
+	Context string
+	Cluster string
+	Profile string
+	Reason  string
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove kubeconfig entries for deleted clusters and removed profiles",
+	Long: `Find the contexts asp-eks added to ~/.kube/config whose EKS cluster doesn't exist anymore or
+whose AWS profile was removed from ~/.aws/config, and remove them together with their cluster
+and user entries. Asks for confirmation unless --yes is given.
+
+Contexts that can't be checked, for example because the SSO session of their profile expired or
+their profile now points at another account than the cluster's, are kept.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		out := cmd.OutOrStdout()
+
+		configPath := getDefaultKubeConfigPath()
+		if configPath == "" {
+			fmt.Fprintln(out, "Could not determine kubeconfig path")
+			return
+		}
+		kubeConfig, err := clientcmd.LoadFromFile(configPath)
+		if err != nil {
+			fmt.Fprintln(out, "Failed to load kubeconfig:", err)
+			return
+		}
+		profiles, err := awsutils.GetAwsProfiles()
+		if err != nil {
+			fmt.Fprintln(out, "Error:", err)
+			return
+		}
+
+		stale := findStaleContexts(context.Background(), out, kubeConfig, profiles)
+		if len(stale) == 0 {
+			fmt.Fprintln(out, "No stale kubeconfig entries found")
+			return
+		}
+
+		fmt.Fprintln(out, "Stale kubeconfig entries:")
+		for _, entry := range stale {
+			fmt.Fprintf(out, "  %s (%s): %s\n", entry.Context, entry.Cluster, entry.Reason)
+		}
+
+		if !cleanYes {
+			fmt.Fprintf(out, "Remove %d context(s)? [y/N]: ", len(stale))
+			reader := bufio.NewReader(os.Stdin)
+			input, _ := reader.ReadString('\n')
+			if answer := strings.ToLower(strings.TrimSpace(input)); answer != "y" && answer != "yes" {
+				fmt.Fprintln(out, "Aborted")
+				return
+			}
+		}
+
+		removeContexts(kubeConfig, stale)
+		if err := clientcmd.WriteToFile(*kubeConfig, configPath); err != nil {
+			fmt.Fprintln(out, "Failed to write kubeconfig:", err)
+			return
+		}
+		fmt.Fprintf(out, "Removed %d context(s) from %s\n", len(stale), configPath)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cleanCmd)
+	cleanCmd.Flags().BoolVarP(&cleanYes, "yes", "y", false, "Remove the entries without asking for confirmation")
+}
+
+// findStaleContexts returns the asp-eks contexts whose profile is not in profiles or whose
+// cluster was deleted. Every cluster is described at most once. A cluster is only described when
+// the profile is still for the account in the cluster's ARN, since any other account answers that
+// the cluster doesn't exist.
+func findStaleContexts(ctx context.Context, out io.Writer, kubeConfig *api.Config, profiles []string) []staleContext {
+	knownProfiles := make(map[string]bool)
+	for _, profile := range profiles {
+		knownProfiles[profile] = true
+	}
+
+	var contextNames []string
+	for name := range kubeConfig.Contexts {
+		contextNames = append(contextNames, name)
+	}
+	sort.Strings(contextNames)
+
+	accounts := make(map[string]string)
+	var stale []staleContext
+	for _, name := range contextNames {
+		kubeContext := kubeConfig.Contexts[name]
+		region, accountID, clusterName, ok := parseClusterArn(kubeContext.Cluster)
+		profile := contextProfile(kubeConfig, name)
+		if !ok || profile == "" || kubeContext.AuthInfo != kubeContext.Cluster {
+			// Not created by asp-eks
+			continue
+		}
+
+		entry := staleContext{Context: name, Cluster: kubeContext.Cluster, Profile: profile}
+		if !knownProfiles[profile] {
+			entry.Reason = fmt.Sprintf("profile %s no longer exists", profile)
+			stale = append(stale, entry)
+			continue
+		}
+
+		profileAccount, checked := accounts[profile]
+		if !checked {
+			var err error
+			if profileAccount, err = profileAccountID(ctx, profile); err != nil {
+				fmt.Fprintf(out, "Warning: could not determine the account of profile %s, keeping its contexts: %v\n", profile, err)
+			}
+			accounts[profile] = profileAccount
+		}
+		if profileAccount == "" {
+			continue
+		}
+		if profileAccount != accountID {
+			fmt.Fprintf(out, "Warning: could not check %s, profile %s is not for account %s, keeping it\n", name, profile, accountID)
+			continue
+		}
+
+		exists, err := clusterExistenceChecker(ctx, profile, region, clusterName)
+		if err != nil {
+			fmt.Fprintf(out, "Warning: could not check %s with profile %s, keeping it: %v\n", name, profile, err)
+			continue
+		}
+		if !exists {
+			entry.Reason = "cluster no longer exists"
+			stale = append(stale, entry)
+		}
+	}
+	return stale
+}
+
+// removeContexts deletes the contexts and the cluster and user entries no other context uses
+func removeContexts(kubeConfig *api.Config, stale []staleContext) {
+	for _, entry := range stale {
+		kubeContext := kubeConfig.Contexts[entry.Context]
+		delete(kubeConfig.Contexts, entry.Context)
+		if kubeConfig.CurrentContext == entry.Context {
+			kubeConfig.CurrentContext = ""
+		}
+		if kubeContext == nil {
+			continue
+		}
+
+		clusterUsed, authInfoUsed := false, false
+		for _, other := range kubeConfig.Contexts {
+			clusterUsed = clusterUsed || other.Cluster == kubeContext.Cluster
+			authInfoUsed = authInfoUsed || other.AuthInfo == kubeContext.AuthInfo
+		}
+		if !clusterUsed {
+			delete(kubeConfig.Clusters, kubeContext.Cluster)
+		}
+		if !authInfoUsed {
+			delete(kubeConfig.AuthInfos, kubeContext.AuthInfo)
+		}
+	}
+}
+
+// profileAccountID returns the account a profile's credentials are for: its sso_account_id, the
+// account of its role_arn or, for other credentials, the account of its caller identity
+func profileAccountID(ctx context.Context, profile string) (string, error) {
+	profileConfig, err := awsutils.GetProfileConfig(profile)
+	if err == nil && profileConfig["sso_account_id"] != "" {
+		return profileConfig["sso_account_id"], nil
+	}
+	if err == nil && profileConfig["role_arn"] != "" {
+		if accountID, _ := roleFromPrincipalArn(profileConfig["role_arn"]); accountID != "" {
+			return accountID, nil
+		}
+	}
+	accountID, _, err := callerIdentityFetcher(ctx, profile)
+	return accountID, err
+}
+
+// clusterExists describes the cluster with the profile's credentials, treating a
+// ResourceNotFoundException as a deleted cluster
+func clusterExists(ctx context.Context, profile, region, clusterName string) (bool, error) {
+	cfg, err := loadAWSConfig(ctx, profile, config.WithRegion(region))
+	if err != nil {
+		return false, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	_, err = eks.NewFromConfig(cfg).DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(clusterName)})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestCleanCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[profile payments-prod]
+sso_account_id = 123456789012
+region = eu-west-1
+
+[profile payments-dev]
+region = eu-west-1
+`), 0600)
+
+	// Contexts as written by createOrUpdateKubeContext, plus one added by other tooling
+	contexts := map[string]struct{ arn, profile string }{
+		"live":      {"arn:aws:eks:eu-west-1:123456789012:cluster/live", "payments-prod"},
+		"deleted":   {"arn:aws:eks:eu-west-1:123456789012:cluster/deleted", "payments-prod"},
+		"orphaned":  {"arn:aws:eks:eu-west-1:210987654321:cluster/orphaned", "removed-profile"},
+		"unchecked": {"arn:aws:eks:eu-west-1:345678901234:cluster/unchecked", "payments-dev"},
+		"moved":     {"arn:aws:eks:eu-west-1:999999999999:cluster/moved", "payments-prod"},
+	}
+	var kubeConfig strings.Builder
+	kubeConfig.WriteString("apiVersion: v1\nkind: Config\ncurrent-context: deleted\nclusters:\n")
+	for _, c := range contexts {
+		kubeConfig.WriteString("- name: " + c.arn + "\n  cluster:\n    server: https://example.com\n")
+	}
+	kubeConfig.WriteString("- name: kind-local\n  cluster:\n    server: https://127.0.0.1:6443\ncontexts:\n")
+	for name, c := range contexts {
+		kubeConfig.WriteString("- name: " + name + "\n  context:\n    cluster: " + c.arn + "\n    user: " + c.arn + "\n")
+	}
+	kubeConfig.WriteString("- name: kind-local\n  context:\n    cluster: kind-local\n    user: kind-local\nusers:\n")
+	for _, c := range contexts {
+		kubeConfig.WriteString("- name: " + c.arn + "\n  user:\n    exec:\n      apiVersion: client.authentication.k8s.io/v1beta1\n" +
+			"      command: aws\n      args: [\"eks\", \"get-token\"]\n      env:\n      - name: AWS_PROFILE\n        value: " + c.profile + "\n")
+	}
+	kubeConfig.WriteString("- name: kind-local\n  user:\n    token: secret\n")
+
+	configPath := filepath.Join(home, ".kube", "config")
+	os.MkdirAll(filepath.Dir(configPath), 0755)
+	os.WriteFile(configPath, []byte(kubeConfig.String()), 0600)
+
+	originalChecker, originalFetcher := clusterExistenceChecker, callerIdentityFetcher
+	defer func() { clusterExistenceChecker, callerIdentityFetcher = originalChecker, originalFetcher }()
+	callerIdentityFetcher = func(ctx context.Context, profile string) (string, string, error) {
+		return "345678901234", "arn:aws:sts::345678901234:assumed-role/operator/jane", nil
+	}
+	var checked []string
+	clusterExistenceChecker = func(ctx context.Context, profile, region, clusterName string) (bool, error) {
+		checked = append(checked, clusterName)
+		switch clusterName {
+		case "deleted":
+			return false, nil
+		case "unchecked":
+			return false, errors.New("token expired")
+		case "moved":
+			return false, nil
+		}
+		return true, nil
+	}
+
+	var output bytes.Buffer
+	rootCmd.SetOut(&output)
+	rootCmd.SetArgs([]string{"clean", "--yes"})
+	defer func() { cleanYes = false }()
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if strings.Join(checked, ",") != "deleted,live,unchecked" {
+		t.Errorf("Expected only clusters of existing profiles in their account to be described, got %v", checked)
+	}
+
+	result, err := clientcmd.LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load kubeconfig: %v", err)
+	}
+	for _, name := range []string{"live", "unchecked", "moved", "kind-local"} {
+		if result.Contexts[name] == nil {
+			t.Errorf("Expected context %s to be kept", name)
+		}
+	}
+	for _, name := range []string{"deleted", "orphaned"} {
+		if result.Contexts[name] != nil || result.Clusters[contexts[name].arn] != nil || result.AuthInfos[contexts[name].arn] != nil {
+			t.Errorf("Expected context %s with its cluster and user to be removed", name)
+		}
+	}
+	if result.CurrentContext != "" {
+		t.Errorf("Expected the removed current context to be unset, got %s", result.CurrentContext)
+	}
+	if !strings.Contains(output.String(), "orphaned (arn:aws:eks:eu-west-1:210987654321:cluster/orphaned): profile removed-profile no longer exists") ||
+		!strings.Contains(output.String(), "could not check moved, profile payments-prod is not for account 999999999999") ||
+		!strings.Contains(output.String(), "Removed 2 context(s)") {
+		t.Errorf("Unexpected output: %s", output.String())
+	}
+}
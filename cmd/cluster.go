@@ -6,7 +6,6 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 )
 
@@ -20,6 +19,8 @@ type ClusterInfo struct {
 	AuthCommand     string
 	AuthArgs        []string
 	AuthEnv         map[string]string
+	Tags            map[string]string
+	Status          string
 }
 
 // ClusterProvider defines the interface for discovering and describing clusters
@@ -33,7 +34,7 @@ type ClusterProvider interface {
 type AWSClusterProvider struct{}
 
 func (p *AWSClusterProvider) ListClusters(ctx context.Context, profile string) ([]string, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(profile))
+	cfg, err := loadAWSConfig(ctx, profile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -47,7 +48,7 @@ func (p *AWSClusterProvider) ListClusters(ctx context.Context, profile string) (
 }
 
 func (p *AWSClusterProvider) GetClusterInfo(ctx context.Context, profile, clusterName string) (*ClusterInfo, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(profile))
+	cfg, err := loadAWSConfig(ctx, profile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -60,21 +61,22 @@ func (p *AWSClusterProvider) GetClusterInfo(ctx context.Context, profile, cluste
 		return nil, fmt.Errorf("failed to describe EKS cluster: %w", err)
 	}
 
+	// Clusters that are still being created have no endpoint and certificate authority yet, they
+	// are returned anyway so their status can be shown
 	cluster := clusterOutput.Cluster
-	if cluster.CertificateAuthority == nil || cluster.CertificateAuthority.Data == nil {
-		return nil, fmt.Errorf("cluster certificate authority data is nil")
-	}
-
-	ca, err := base64.StdEncoding.DecodeString(*cluster.CertificateAuthority.Data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode certificate authority data: %w", err)
+	var ca []byte
+	if cluster.CertificateAuthority != nil && cluster.CertificateAuthority.Data != nil {
+		ca, err = base64.StdEncoding.DecodeString(*cluster.CertificateAuthority.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode certificate authority data: %w", err)
+		}
 	}
 
 	region := cfg.Region
 
 	return &ClusterInfo{
 		Name:            *cluster.Name,
-		Endpoint:        *cluster.Endpoint,
+		Endpoint:        aws.ToString(cluster.Endpoint),
 		CertificateData: ca,
 		Region:          region,
 		Arn:             *cluster.Arn,
@@ -88,11 +90,13 @@ func (p *AWSClusterProvider) GetClusterInfo(ctx context.Context, profile, cluste
 		AuthEnv: map[string]string{
 			"AWS_PROFILE": profile,
 		},
+		Tags:   cluster.Tags,
+		Status: string(cluster.Status),
 	}, nil
 }
 
 func (p *AWSClusterProvider) GetRegion(ctx context.Context, profile string) (string, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(profile))
+	cfg, err := loadAWSConfig(ctx, profile)
 	if err != nil {
 		return "", fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/eimarfandino/asp-eks/awsutils"
+)
+
+// mfaTokenProvider asks for the MFA code of a role that requires one - can be mocked in tests
+var mfaTokenProvider = promptMFAToken
+
+var (
+	mfaMu sync.Mutex
+
+	// roleCredentials keeps the credentials of assumed roles per profile, so an MFA code is only
+	// asked once per run instead of once per AWS client
+	roleCredentialsMu sync.Mutex
+	roleCredentials   = make(map[string]aws.CredentialsProvider)
+)
+
+// loadAWSConfig loads the shared config of the profile. Assumed roles ask for MFA codes on the
+// terminal and their credentials are reused by later calls for the same profile.
+func loadAWSConfig(ctx context.Context, profile string, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+	roleCredentialsMu.Lock()
+	cached := roleCredentials[profile]
+	roleCredentialsMu.Unlock()
+
+	opts := []func(*config.LoadOptions) error{
+		config.WithSharedConfigProfile(profile),
+		config.WithAssumeRoleCredentialOptions(func(o *stscreds.AssumeRoleOptions) {
+			o.TokenProvider = mfaTokenProvider
+		}),
+	}
+	if cached != nil {
+		opts = append(opts, config.WithCredentialsProvider(cached))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, append(opts, optFns...)...)
+	if err != nil || cached != nil {
+		return cfg, err
+	}
+
+	if profileConfig, err := awsutils.GetProfileConfig(profile); err == nil && awsutils.GetProfileKind(profileConfig) == awsutils.ProfileKindAssumeRole {
+		roleCredentialsMu.Lock()
+		roleCredentials[profile] = cfg.Credentials
+		roleCredentialsMu.Unlock()
+	}
+	return cfg, nil
+}
+
+// promptMFAToken reads an MFA code from stdin, prompting on the informational output so the
+// prompt doesn't end up in 'use --export' output
+func promptMFAToken() (string, error) {
+	mfaMu.Lock()
+	defer mfaMu.Unlock()
+
+	fmt.Fprint(outputWriter, "MFA code: ")
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read MFA code: %w", err)
+	}
+	return strings.TrimSpace(input), nil
+}
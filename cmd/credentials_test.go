@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/eimarfandino/asp-eks/awsutils"
+)
+
+const chainedAWSConfig = `[sso-session acme]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = eu-central-1
+
+[profile identity-sso]
+sso_session = acme
+sso_account_id = 111111111111
+sso_role_name = operator
+region = eu-west-1
+
+[profile payments-admin]
+role_arn = arn:aws:iam::222222222222:role/admin
+source_profile = payments-jump
+mfa_serial = arn:aws:iam::111111111111:mfa/jane
+region = eu-west-1
+
+[profile payments-jump]
+role_arn = arn:aws:iam::222222222222:role/jump
+source_profile = identity-sso
+
+[profile ci]
+credential_process = /usr/local/bin/issue-credentials
+region = eu-west-1
+
+[profile self-sourced]
+role_arn = arn:aws:iam::333333333333:role/deploy
+source_profile = self-sourced
+aws_access_key_id = AKIAEXAMPLE
+aws_secret_access_key = secret
+
+[profile loop-a]
+role_arn = arn:aws:iam::333333333333:role/a
+source_profile = loop-b
+
+[profile loop-b]
+role_arn = arn:aws:iam::333333333333:role/b
+source_profile = loop-a
+`
+
+func writeChainedAWSConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(home, ".aws", "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(home, ".aws", "credentials"))
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(chainedAWSConfig), 0600)
+	os.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte("[legacy]\naws_access_key_id = AKIAEXAMPLE\naws_secret_access_key = secret\n"), 0600)
+}
+
+func TestGetProfileChain(t *testing.T) {
+	writeChainedAWSConfig(t)
+
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{"payments-admin", "payments-admin:assume-role payments-jump:assume-role identity-sso:sso"},
+		{"identity-sso", "identity-sso:sso"},
+		{"ci", "ci:credential_process"},
+		{"self-sourced", "self-sourced:assume-role self-sourced:static"},
+		{"legacy", "legacy:static"},
+	}
+	for _, tt := range tests {
+		chain, err := awsutils.GetProfileChain(tt.profile)
+		if err != nil {
+			t.Fatalf("GetProfileChain(%s) failed: %v", tt.profile, err)
+		}
+		var links []string
+		for _, link := range chain {
+			links = append(links, link.Name+":"+string(link.Kind))
+		}
+		if got := strings.Join(links, " "); got != tt.want {
+			t.Errorf("GetProfileChain(%s) = %s, want %s", tt.profile, got, tt.want)
+		}
+	}
+
+	if _, err := awsutils.GetProfileChain("loop-a"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+}
+
+func TestEnsureSSOLogsInAtRootOfChain(t *testing.T) {
+	writeChainedAWSConfig(t)
+
+	var commands []string
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		return fakeExecCommand(name, args...)
+	}
+	defer func() { execCommand = exec.Command }()
+
+	originalValidator := credentialsValidator
+	defer func() { credentialsValidator = originalValidator }()
+	// Every validation of payments-admin asks for an MFA code, so it must only happen after the login
+	var validations []string
+	credentialsValidator = func(ctx context.Context, profile string) bool {
+		validations = append(validations, fmt.Sprintf("%s after %d logins", profile, len(commands)))
+		return len(commands) > 0
+	}
+
+	var output bytes.Buffer
+	outputWriter = &output
+	defer func() { outputWriter = os.Stdout }()
+
+	if err := ensureSSO("payments-admin"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(commands) != 1 || commands[0] != "aws sso login --profile identity-sso" {
+		t.Errorf("Expected one SSO login for the root profile, got %v", commands)
+	}
+	if len(validations) != 1 || validations[0] != "payments-admin after 1 logins" {
+		t.Errorf("Expected a single validation after the login, got %v", validations)
+	}
+	if !strings.Contains(output.String(), "payments-admin (MFA) -> payments-jump -> identity-sso") {
+		t.Errorf("Expected the role chain in the output, got: %s", output.String())
+	}
+
+	// With a valid SSO login at the root, the chain is validated once without logging in
+	writeSSOCacheFile(t, os.Getenv("HOME"), "acme.json", map[string]interface{}{
+		"startUrl":    "https://acme.awsapps.com/start",
+		"region":      "eu-central-1",
+		"accessToken": "token",
+		"expiresAt":   time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+	commands, validations = nil, nil
+	credentialsValidator = func(ctx context.Context, profile string) bool {
+		validations = append(validations, profile)
+		return true
+	}
+	if err := ensureSSO("payments-admin"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(commands) != 0 || len(validations) != 1 {
+		t.Errorf("Expected one validation and no login, got %v and %v", validations, commands)
+	}
+}
+
+func TestEnsureSSOSkipsLoginForNonSSOCredentials(t *testing.T) {
+	writeChainedAWSConfig(t)
+
+	var commands []string
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		return fakeExecCommand(name, args...)
+	}
+	defer func() { execCommand = exec.Command }()
+
+	originalValidator := credentialsValidator
+	defer func() { credentialsValidator = originalValidator }()
+	credentialsValidator = func(ctx context.Context, profile string) bool { return false }
+
+	var output bytes.Buffer
+	outputWriter = &output
+	defer func() { outputWriter = os.Stdout }()
+
+	for profile, want := range map[string]string{
+		"ci":           "credential_process of profile ci",
+		"legacy":       "static credentials of profile legacy",
+		"self-sourced": "static credentials of profile self-sourced",
+	} {
+		err := ensureSSO(profile)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ensureSSO(%s) = %v, want error containing %q", profile, err, want)
+		}
+	}
+	if len(commands) > 0 {
+		t.Errorf("Expected no SSO login, got %v", commands)
+	}
+}
+
+func TestLoadAWSConfigReusesRoleCredentials(t *testing.T) {
+	writeChainedAWSConfig(t)
+	defer func() { roleCredentials = make(map[string]aws.CredentialsProvider) }()
+
+	first, err := loadAWSConfig(context.Background(), "self-sourced")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := loadAWSConfig(context.Background(), "self-sourced")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.Credentials != second.Credentials {
+		t.Error("Expected the credentials of the assumed role to be reused")
+	}
+
+	if _, err := loadAWSConfig(context.Background(), "ci"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := roleCredentials["ci"]; ok {
+		t.Error("Expected only assumed role credentials to be kept")
+	}
+}
@@ -0,0 +1,463 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/spf13/cobra"
+)
+
+var describeOutput string
+
+// clusterReporter describes a cluster with its nodegroups, Fargate profiles and add-ons - can be
+// mocked in tests
+var clusterReporter = fetchClusterReport
+
+// clusterReport is everything 'describe' shows about a cluster
+type clusterReport struct {
+	Name                   string            `json:"name"`
+	Arn                    string            `json:"arn"`
+	Status                 string            `json:"status"`
+	Version                string            `json:"version"`
+	PlatformVersion        string            `json:"platformVersion"`
+	CreatedAt              time.Time         `json:"createdAt"`
+	Endpoint               string            `json:"endpoint"`
+	EndpointPublicAccess   bool              `json:"endpointPublicAccess"`
+	EndpointPrivateAccess  bool              `json:"endpointPrivateAccess"`
+	PublicAccessCidrs      []string          `json:"publicAccessCidrs,omitempty"`
+	VpcID                  string            `json:"vpcId"`
+	SubnetIDs              []string          `json:"subnetIds"`
+	ClusterSecurityGroupID string            `json:"clusterSecurityGroupId,omitempty"`
+	SecurityGroupIDs       []string          `json:"securityGroupIds,omitempty"`
+	ServiceCidr            string            `json:"serviceCidr,omitempty"`
+	EnabledLogTypes        []string          `json:"enabledLogTypes"`
+	DisabledLogTypes       []string          `json:"disabledLogTypes"`
+	HealthIssues           []string          `json:"healthIssues,omitempty"`
+	Tags                   map[string]string `json:"tags,omitempty"`
+	Nodegroups             []nodegroupReport `json:"nodegroups"`
+	FargateProfiles        []fargateReport   `json:"fargateProfiles"`
+	Addons                 []addonReport     `json:"addons"`
+
+	// Errors of the sections that could not be listed, the rest of the report is still shown
+	NodegroupsError      string `json:"nodegroupsError,omitempty"`
+	FargateProfilesError string `json:"fargateProfilesError,omitempty"`
+	AddonsError          string `json:"addonsError,omitempty"`
+}
+
+type nodegroupReport struct {
+	Name           string   `json:"name"`
+	Status         string   `json:"status"`
+	Version        string   `json:"version"`
+	ReleaseVersion string   `json:"releaseVersion"`
+	InstanceTypes  []string `json:"instanceTypes"`
+	CapacityType   string   `json:"capacityType"`
+	MinSize        int32    `json:"minSize"`
+	DesiredSize    int32    `json:"desiredSize"`
+	MaxSize        int32    `json:"maxSize"`
+	HealthIssues   []string `json:"healthIssues,omitempty"`
+}
+
+type fargateReport struct {
+	Name       string   `json:"name"`
+	Status     string   `json:"status"`
+	Namespaces []string `json:"namespaces"`
+}
+
+type addonReport struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Status       string   `json:"status"`
+	HealthIssues []string `json:"healthIssues,omitempty"`
+}
+
+var describeCmd = &cobra.Command{
+	Use:   "describe <[profile:]cluster|alias>",
+	Short: "Show status, versions, networking, nodegroups and add-ons of an EKS cluster",
+	Long: `Describe a cluster without opening the console: status and health, Kubernetes and platform
+version, API server endpoint access and public CIDRs, control plane logging, VPC, subnets and
+security groups, tags, managed nodegroups, Fargate profiles and add-ons with their versions.
+
+Without a profile, AWS_PROFILE is used. A profile without a cluster (or an alias for one) must
+see exactly one cluster.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if describeOutput != "text" && describeOutput != "json" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Unsupported output format %q, use text or json\n", describeOutput)
+			os.Exit(1)
+		}
+
+		// Keep stdout for the report, login messages go to stderr
+		originalWriter := outputWriter
+		outputWriter = cmd.ErrOrStderr()
+		defer func() { outputWriter = originalWriter }()
+
+		report, err := describeTarget(context.Background(), args[0])
+		if err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		if describeOutput == "json" {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(report)
+			return
+		}
+		printClusterReport(out, report)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(describeCmd)
+	describeCmd.Flags().StringVarP(&describeOutput, "output", "o", "text", "Output format: text or json")
+}
+
+// describeTarget resolves a [profile:]cluster target or alias and describes the cluster
+func describeTarget(ctx context.Context, target string) (*clusterReport, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return nil, err
+	}
+	aliases := loadAliases(settings)
+
+	var profile, cluster string
+	if _, ok := aliases[target]; ok || strings.Contains(target, ":") {
+		profile, cluster = resolveTarget(aliases, target)
+	} else {
+		profile, cluster = os.Getenv("AWS_PROFILE"), target
+		if profile == "" {
+			return nil, fmt.Errorf("no profile given, use <profile>:%s or set AWS_PROFILE", target)
+		}
+	}
+
+	if err := ensureSSO(profile); err != nil {
+		return nil, fmt.Errorf("failed to ensure SSO login: %w", err)
+	}
+	if cluster == "" {
+		clusterInfo, err := resolveClusterInfo(ctx, profile, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		cluster = clusterInfo.Name
+	}
+
+	region, err := clusterProvider.GetRegion(ctx, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get region: %w", err)
+	}
+	return clusterReporter(ctx, profile, region, cluster)
+}
+
+func printClusterReport(w io.Writer, r *clusterReport) {
+	fmt.Fprintf(w, "Name:             %s\n", r.Name)
+	fmt.Fprintf(w, "ARN:              %s\n", r.Arn)
+	fmt.Fprintf(w, "Status:           %s\n", r.Status)
+	fmt.Fprintf(w, "Version:          %s (platform %s)\n", r.Version, r.PlatformVersion)
+	if !r.CreatedAt.IsZero() {
+		fmt.Fprintf(w, "Created:          %s\n", r.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Endpoint:         %s\n", r.Endpoint)
+	access := endpointAccessMode(r.EndpointPublicAccess, r.EndpointPrivateAccess)
+	if r.EndpointPublicAccess && len(r.PublicAccessCidrs) > 0 {
+		access += " (public CIDRs: " + strings.Join(r.PublicAccessCidrs, ", ") + ")"
+	}
+	fmt.Fprintf(w, "Endpoint access:  %s\n", access)
+	fmt.Fprintf(w, "VPC:              %s\n", r.VpcID)
+	fmt.Fprintf(w, "Subnets:          %s\n", strings.Join(r.SubnetIDs, ", "))
+	securityGroups := append([]string{}, r.SecurityGroupIDs...)
+	if r.ClusterSecurityGroupID != "" {
+		securityGroups = append([]string{r.ClusterSecurityGroupID + " (cluster)"}, securityGroups...)
+	}
+	fmt.Fprintf(w, "Security groups:  %s\n", strings.Join(securityGroups, ", "))
+	if r.ServiceCidr != "" {
+		fmt.Fprintf(w, "Service CIDR:     %s\n", r.ServiceCidr)
+	}
+	logging := "none"
+	if len(r.EnabledLogTypes) > 0 {
+		logging = strings.Join(r.EnabledLogTypes, ", ")
+	}
+	if len(r.DisabledLogTypes) > 0 {
+		logging += " (disabled: " + strings.Join(r.DisabledLogTypes, ", ") + ")"
+	}
+	fmt.Fprintf(w, "Logging:          %s\n", logging)
+	if len(r.HealthIssues) == 0 {
+		fmt.Fprintln(w, "Health:           no issues")
+	} else {
+		fmt.Fprintln(w, "Health:")
+		for _, issue := range r.HealthIssues {
+			fmt.Fprintf(w, "  %s\n", issue)
+		}
+	}
+
+	if len(r.Tags) > 0 {
+		fmt.Fprintln(w, "\nTags:")
+		keys := make([]string, 0, len(r.Tags))
+		for key := range r.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "  %s=%s\n", key, r.Tags[key])
+		}
+	}
+
+	fmt.Fprintln(w, "\nNodegroups:")
+	if r.NodegroupsError != "" {
+		fmt.Fprintf(w, "  unavailable: %s\n", r.NodegroupsError)
+	} else if len(r.Nodegroups) == 0 {
+		fmt.Fprintln(w, "  none")
+	} else {
+		fmt.Fprintf(w, "  %-30s %-12s %-8s %-22s %-20s %-10s %s\n", "NAME", "STATUS", "VERSION", "RELEASE", "INSTANCE TYPES", "CAPACITY", "MIN/DESIRED/MAX")
+		for _, ng := range r.Nodegroups {
+			fmt.Fprintf(w, "  %-30s %-12s %-8s %-22s %-20s %-10s %d/%d/%d\n", ng.Name, ng.Status, ng.Version, ng.ReleaseVersion,
+				strings.Join(ng.InstanceTypes, ","), ng.CapacityType, ng.MinSize, ng.DesiredSize, ng.MaxSize)
+			for _, issue := range ng.HealthIssues {
+				fmt.Fprintf(w, "    %s\n", issue)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "\nFargate profiles:")
+	if r.FargateProfilesError != "" {
+		fmt.Fprintf(w, "  unavailable: %s\n", r.FargateProfilesError)
+	} else if len(r.FargateProfiles) == 0 {
+		fmt.Fprintln(w, "  none")
+	} else {
+		fmt.Fprintf(w, "  %-30s %-12s %s\n", "NAME", "STATUS", "NAMESPACES")
+		for _, profile := range r.FargateProfiles {
+			fmt.Fprintf(w, "  %-30s %-12s %s\n", profile.Name, profile.Status, strings.Join(profile.Namespaces, ", "))
+		}
+	}
+
+	fmt.Fprintln(w, "\nAdd-ons:")
+	if r.AddonsError != "" {
+		fmt.Fprintf(w, "  unavailable: %s\n", r.AddonsError)
+	} else if len(r.Addons) == 0 {
+		fmt.Fprintln(w, "  none")
+	} else {
+		fmt.Fprintf(w, "  %-30s %-28s %s\n", "NAME", "VERSION", "STATUS")
+		for _, addon := range r.Addons {
+			fmt.Fprintf(w, "  %-30s %-28s %s\n", addon.Name, addon.Version, addon.Status)
+			for _, issue := range addon.HealthIssues {
+				fmt.Fprintf(w, "    %s\n", issue)
+			}
+		}
+	}
+}
+
+// newClusterReport converts the DescribeCluster response, without nodegroups, Fargate profiles
+// and add-ons
+func newClusterReport(cluster *types.Cluster) *clusterReport {
+	report := &clusterReport{
+		Name:            aws.ToString(cluster.Name),
+		Arn:             aws.ToString(cluster.Arn),
+		Status:          string(cluster.Status),
+		Version:         aws.ToString(cluster.Version),
+		PlatformVersion: aws.ToString(cluster.PlatformVersion),
+		CreatedAt:       aws.ToTime(cluster.CreatedAt),
+		Endpoint:        aws.ToString(cluster.Endpoint),
+		Tags:            cluster.Tags,
+	}
+	if vpc := cluster.ResourcesVpcConfig; vpc != nil {
+		report.EndpointPublicAccess = vpc.EndpointPublicAccess
+		report.EndpointPrivateAccess = vpc.EndpointPrivateAccess
+		report.PublicAccessCidrs = vpc.PublicAccessCidrs
+		report.VpcID = aws.ToString(vpc.VpcId)
+		report.SubnetIDs = vpc.SubnetIds
+		report.ClusterSecurityGroupID = aws.ToString(vpc.ClusterSecurityGroupId)
+		report.SecurityGroupIDs = vpc.SecurityGroupIds
+	}
+	if network := cluster.KubernetesNetworkConfig; network != nil {
+		report.ServiceCidr = aws.ToString(network.ServiceIpv4Cidr)
+		if report.ServiceCidr == "" {
+			report.ServiceCidr = aws.ToString(network.ServiceIpv6Cidr)
+		}
+	}
+	if cluster.Logging != nil {
+		for _, setup := range cluster.Logging.ClusterLogging {
+			for _, logType := range setup.Types {
+				if aws.ToBool(setup.Enabled) {
+					report.EnabledLogTypes = append(report.EnabledLogTypes, string(logType))
+				} else {
+					report.DisabledLogTypes = append(report.DisabledLogTypes, string(logType))
+				}
+			}
+		}
+	}
+	if cluster.Health != nil {
+		for _, issue := range cluster.Health.Issues {
+			report.HealthIssues = append(report.HealthIssues, formatIssue(string(issue.Code), issue.Message))
+		}
+	}
+	return report
+}
+
+func newNodegroupReport(nodegroup *types.Nodegroup) nodegroupReport {
+	report := nodegroupReport{
+		Name:           aws.ToString(nodegroup.NodegroupName),
+		Status:         string(nodegroup.Status),
+		Version:        aws.ToString(nodegroup.Version),
+		ReleaseVersion: aws.ToString(nodegroup.ReleaseVersion),
+		InstanceTypes:  nodegroup.InstanceTypes,
+		CapacityType:   string(nodegroup.CapacityType),
+	}
+	if scaling := nodegroup.ScalingConfig; scaling != nil {
+		report.MinSize = aws.ToInt32(scaling.MinSize)
+		report.DesiredSize = aws.ToInt32(scaling.DesiredSize)
+		report.MaxSize = aws.ToInt32(scaling.MaxSize)
+	}
+	if nodegroup.Health != nil {
+		for _, issue := range nodegroup.Health.Issues {
+			report.HealthIssues = append(report.HealthIssues, formatIssue(string(issue.Code), issue.Message))
+		}
+	}
+	return report
+}
+
+func newFargateReport(profile *types.FargateProfile) fargateReport {
+	report := fargateReport{Name: aws.ToString(profile.FargateProfileName), Status: string(profile.Status)}
+	for _, selector := range profile.Selectors {
+		report.Namespaces = append(report.Namespaces, aws.ToString(selector.Namespace))
+	}
+	return report
+}
+
+func newAddonReport(addon *types.Addon) addonReport {
+	report := addonReport{
+		Name:    aws.ToString(addon.AddonName),
+		Version: aws.ToString(addon.AddonVersion),
+		Status:  string(addon.Status),
+	}
+	if addon.Health != nil {
+		for _, issue := range addon.Health.Issues {
+			report.HealthIssues = append(report.HealthIssues, formatIssue(string(issue.Code), issue.Message))
+		}
+	}
+	return report
+}
+
+func formatIssue(code string, message *string) string {
+	if message == nil {
+		return code
+	}
+	return code + ": " + aws.ToString(message)
+}
+
+// describeEKSAPI is the part of the EKS API used to describe a cluster
+type describeEKSAPI interface {
+	DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error)
+	ListNodegroups(ctx context.Context, params *eks.ListNodegroupsInput, optFns ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error)
+	DescribeNodegroup(ctx context.Context, params *eks.DescribeNodegroupInput, optFns ...func(*eks.Options)) (*eks.DescribeNodegroupOutput, error)
+	ListFargateProfiles(ctx context.Context, params *eks.ListFargateProfilesInput, optFns ...func(*eks.Options)) (*eks.ListFargateProfilesOutput, error)
+	DescribeFargateProfile(ctx context.Context, params *eks.DescribeFargateProfileInput, optFns ...func(*eks.Options)) (*eks.DescribeFargateProfileOutput, error)
+	ListAddons(ctx context.Context, params *eks.ListAddonsInput, optFns ...func(*eks.Options)) (*eks.ListAddonsOutput, error)
+	DescribeAddon(ctx context.Context, params *eks.DescribeAddonInput, optFns ...func(*eks.Options)) (*eks.DescribeAddonOutput, error)
+}
+
+// fetchClusterReport describes the cluster and its nodegroups, Fargate profiles and add-ons
+func fetchClusterReport(ctx context.Context, profile, region, clusterName string) (*clusterReport, error) {
+	cfg, err := loadAWSConfig(ctx, profile, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return buildClusterReport(ctx, eks.NewFromConfig(cfg), clusterName)
+}
+
+// buildClusterReport describes the cluster and its nodegroups, Fargate profiles and add-ons.
+// Only failing to describe the cluster is an error, a section that can't be listed, e.g. because
+// the role lacks the permission, keeps its error in the report instead.
+func buildClusterReport(ctx context.Context, client describeEKSAPI, clusterName string) (*clusterReport, error) {
+	out, err := client.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(clusterName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe EKS cluster: %w", err)
+	}
+	report := newClusterReport(out.Cluster)
+
+	if report.Nodegroups, err = fetchNodegroupReports(ctx, client, clusterName); err != nil {
+		report.NodegroupsError = err.Error()
+	}
+	if report.FargateProfiles, err = fetchFargateReports(ctx, client, clusterName); err != nil {
+		report.FargateProfilesError = err.Error()
+	}
+	if report.Addons, err = fetchAddonReports(ctx, client, clusterName); err != nil {
+		report.AddonsError = err.Error()
+	}
+	return report, nil
+}
+
+func fetchNodegroupReports(ctx context.Context, client describeEKSAPI, clusterName string) ([]nodegroupReport, error) {
+	var reports []nodegroupReport
+	nodegroups := eks.NewListNodegroupsPaginator(client, &eks.ListNodegroupsInput{ClusterName: aws.String(clusterName)})
+	for nodegroups.HasMorePages() {
+		page, err := nodegroups.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodegroups: %w", err)
+		}
+		for _, name := range page.Nodegroups {
+			nodegroup, err := client.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{
+				ClusterName:   aws.String(clusterName),
+				NodegroupName: aws.String(name),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe nodegroup %s: %w", name, err)
+			}
+			reports = append(reports, newNodegroupReport(nodegroup.Nodegroup))
+		}
+	}
+	return reports, nil
+}
+
+func fetchFargateReports(ctx context.Context, client describeEKSAPI, clusterName string) ([]fargateReport, error) {
+	var reports []fargateReport
+	fargateProfiles := eks.NewListFargateProfilesPaginator(client, &eks.ListFargateProfilesInput{ClusterName: aws.String(clusterName)})
+	for fargateProfiles.HasMorePages() {
+		page, err := fargateProfiles.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Fargate profiles: %w", err)
+		}
+		for _, name := range page.FargateProfileNames {
+			fargateProfile, err := client.DescribeFargateProfile(ctx, &eks.DescribeFargateProfileInput{
+				ClusterName:        aws.String(clusterName),
+				FargateProfileName: aws.String(name),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe Fargate profile %s: %w", name, err)
+			}
+			reports = append(reports, newFargateReport(fargateProfile.FargateProfile))
+		}
+	}
+	return reports, nil
+}
+
+func fetchAddonReports(ctx context.Context, client describeEKSAPI, clusterName string) ([]addonReport, error) {
+	var reports []addonReport
+	addons := eks.NewListAddonsPaginator(client, &eks.ListAddonsInput{ClusterName: aws.String(clusterName)})
+	for addons.HasMorePages() {
+		page, err := addons.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list add-ons: %w", err)
+		}
+		for _, name := range page.Addons {
+			addon, err := client.DescribeAddon(ctx, &eks.DescribeAddonInput{
+				ClusterName: aws.String(clusterName),
+				AddonName:   aws.String(name),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe add-on %s: %w", name, err)
+			}
+			reports = append(reports, newAddonReport(addon.Addon))
+		}
+	}
+	return reports, nil
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks/types"
+)
+
+func TestClusterReportFromDescribeCluster(t *testing.T) {
+	report := newClusterReport(&types.Cluster{
+		Name:            aws.String("payments-eks-1"),
+		Arn:             aws.String("arn:aws:eks:eu-west-1:123456789012:cluster/payments-eks-1"),
+		Status:          types.ClusterStatusActive,
+		Version:         aws.String("1.29"),
+		PlatformVersion: aws.String("eks.7"),
+		CreatedAt:       aws.Time(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)),
+		ResourcesVpcConfig: &types.VpcConfigResponse{
+			EndpointPublicAccess:   true,
+			EndpointPrivateAccess:  true,
+			PublicAccessCidrs:      []string{"203.0.113.0/24"},
+			VpcId:                  aws.String("vpc-0abc"),
+			SubnetIds:              []string{"subnet-a", "subnet-b"},
+			ClusterSecurityGroupId: aws.String("sg-cluster"),
+		},
+		KubernetesNetworkConfig: &types.KubernetesNetworkConfigResponse{ServiceIpv4Cidr: aws.String("172.20.0.0/16")},
+		Logging: &types.Logging{ClusterLogging: []types.LogSetup{
+			{Enabled: aws.Bool(true), Types: []types.LogType{types.LogTypeApi, types.LogTypeAudit}},
+			{Enabled: aws.Bool(false), Types: []types.LogType{types.LogTypeScheduler}},
+		}},
+		Health: &types.ClusterHealth{Issues: []types.ClusterIssue{
+			{Code: types.ClusterIssueCodeEc2SubnetNotFound, Message: aws.String("subnet-b does not exist")},
+		}},
+		Tags: map[string]string{"team": "payments", "env": "prod"},
+	})
+	report.Nodegroups = []nodegroupReport{newNodegroupReport(&types.Nodegroup{
+		NodegroupName:  aws.String("general"),
+		Status:         types.NodegroupStatusActive,
+		Version:        aws.String("1.29"),
+		ReleaseVersion: aws.String("1.29.0-20240301"),
+		InstanceTypes:  []string{"m6i.large"},
+		CapacityType:   types.CapacityTypesOnDemand,
+		ScalingConfig:  &types.NodegroupScalingConfig{MinSize: aws.Int32(2), DesiredSize: aws.Int32(3), MaxSize: aws.Int32(6)},
+	})}
+	report.FargateProfiles = []fargateReport{newFargateReport(&types.FargateProfile{
+		FargateProfileName: aws.String("batch"),
+		Status:             types.FargateProfileStatusActive,
+		Selectors:          []types.FargateProfileSelector{{Namespace: aws.String("batch")}, {Namespace: aws.String("jobs")}},
+	})}
+	report.Addons = []addonReport{newAddonReport(&types.Addon{
+		AddonName:    aws.String("vpc-cni"),
+		AddonVersion: aws.String("v1.16.0-eksbuild.1"),
+		Status:       types.AddonStatusDegraded,
+		Health:       &types.AddonHealth{Issues: []types.AddonIssue{{Code: types.AddonIssueCodeInsufficientNumberOfReplicas}}},
+	})}
+
+	var output bytes.Buffer
+	printClusterReport(&output, report)
+	out := output.String()
+
+	for _, want := range []string{
+		"Version:          1.29 (platform eks.7)",
+		"Endpoint access:  public+private (public CIDRs: 203.0.113.0/24)",
+		"Subnets:          subnet-a, subnet-b",
+		"Security groups:  sg-cluster (cluster)",
+		"Service CIDR:     172.20.0.0/16",
+		"Logging:          api, audit (disabled: scheduler)",
+		"Ec2SubnetNotFound: subnet-b does not exist",
+		"  env=prod\n  team=payments\n",
+		"1.29.0-20240301",
+		"2/3/6",
+		"batch, jobs",
+		"v1.16.0-eksbuild.1",
+		"    InsufficientNumberOfReplicas\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestDescribeTargetResolvesProfile(t *testing.T) {
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+
+	originalProvider, originalReporter, originalValidator := clusterProvider, clusterReporter, credentialsValidator
+	defer func() {
+		clusterProvider, clusterReporter, credentialsValidator = originalProvider, originalReporter, originalValidator
+	}()
+	clusterProvider = &mockClusterProvider{region: "eu-west-1", clusters: []string{"payments-eks-1"}}
+	credentialsValidator = func(ctx context.Context, profile string) bool { return true }
+	var described string
+	clusterReporter = func(ctx context.Context, profile, region, clusterName string) (*clusterReport, error) {
+		described = profile + "/" + region + "/" + clusterName
+		return &clusterReport{Name: clusterName}, nil
+	}
+
+	tests := []struct {
+		target, awsProfile, want string
+	}{
+		{"payments-prod-operator:payments-eks-1", "", "payments-prod-operator/eu-west-1/payments-eks-1"},
+		{"payments-prod-operator:", "", "payments-prod-operator/eu-west-1/payments-eks-1"},
+		{"tools-eks", "shared-dev", "shared-dev/eu-west-1/tools-eks"},
+	}
+	for _, tt := range tests {
+		t.Setenv("AWS_PROFILE", tt.awsProfile)
+		described = ""
+		if _, err := describeTarget(context.Background(), tt.target); err != nil {
+			t.Fatalf("describeTarget(%q) failed: %v", tt.target, err)
+		}
+		if described != tt.want {
+			t.Errorf("describeTarget(%q) described %q, want %q", tt.target, described, tt.want)
+		}
+	}
+
+	t.Setenv("AWS_PROFILE", "")
+	if _, err := describeTarget(context.Background(), "tools-eks"); err == nil {
+		t.Error("Expected an error for a cluster without profile and no AWS_PROFILE")
+	}
+}
+
+// fakeDescribeEKS serves a cluster with one add-on and no Fargate profiles, and can't list nodegroups
+type fakeDescribeEKS struct{}
+
+func (fakeDescribeEKS) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	return &eks.DescribeClusterOutput{Cluster: &types.Cluster{Name: params.Name, Status: types.ClusterStatusActive}}, nil
+}
+
+func (fakeDescribeEKS) ListNodegroups(ctx context.Context, params *eks.ListNodegroupsInput, optFns ...func(*eks.Options)) (*eks.ListNodegroupsOutput, error) {
+	return nil, errors.New("AccessDeniedException: not authorized to perform eks:ListNodegroups")
+}
+
+func (fakeDescribeEKS) DescribeNodegroup(ctx context.Context, params *eks.DescribeNodegroupInput, optFns ...func(*eks.Options)) (*eks.DescribeNodegroupOutput, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (fakeDescribeEKS) ListFargateProfiles(ctx context.Context, params *eks.ListFargateProfilesInput, optFns ...func(*eks.Options)) (*eks.ListFargateProfilesOutput, error) {
+	return &eks.ListFargateProfilesOutput{}, nil
+}
+
+func (fakeDescribeEKS) DescribeFargateProfile(ctx context.Context, params *eks.DescribeFargateProfileInput, optFns ...func(*eks.Options)) (*eks.DescribeFargateProfileOutput, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func (fakeDescribeEKS) ListAddons(ctx context.Context, params *eks.ListAddonsInput, optFns ...func(*eks.Options)) (*eks.ListAddonsOutput, error) {
+	return &eks.ListAddonsOutput{Addons: []string{"vpc-cni"}}, nil
+}
+
+func (fakeDescribeEKS) DescribeAddon(ctx context.Context, params *eks.DescribeAddonInput, optFns ...func(*eks.Options)) (*eks.DescribeAddonOutput, error) {
+	return &eks.DescribeAddonOutput{Addon: &types.Addon{AddonName: params.AddonName, AddonVersion: aws.String("v1.18.1-eksbuild.1"), Status: types.AddonStatusActive}}, nil
+}
+
+func TestBuildClusterReportKeepsAvailableSections(t *testing.T) {
+	report, err := buildClusterReport(context.Background(), fakeDescribeEKS{}, "payments-eks-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(report.NodegroupsError, "eks:ListNodegroups") || report.FargateProfilesError != "" || report.AddonsError != "" {
+		t.Errorf("Expected only the nodegroups to be unavailable, got %+v", report)
+	}
+
+	var output bytes.Buffer
+	printClusterReport(&output, report)
+	got := output.String()
+	for _, want := range []string{
+		"Nodegroups:\n  unavailable: failed to list nodegroups: AccessDeniedException: not authorized to perform eks:ListNodegroups\n",
+		"Fargate profiles:\n  none\n",
+		"vpc-cni                        v1.18.1-eksbuild.1           ACTIVE",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/eimarfandino/asp-eks/awsutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	discoverInclude     []string
+	discoverExclude     []string
+	discoverRegions     []string
+	discoverConcurrency int
+	discoverOutput      string
+	discoverCached      bool
+)
+
+// clusterLister lists the clusters a profile sees in a region, or in the profile's own region
+// when empty, and returns the region it scanned - can be mocked in tests
+var clusterLister = listClustersInRegion
+
+// clusterLocation is a profile and region through which a cluster can be reached
+type clusterLocation struct {
+	Profile string `json:"profile"`
+	Region  string `json:"region"`
+}
+
+// clusterIndex maps cluster names to the profiles and regions they were found with
+type clusterIndex struct {
+	GeneratedAt time.Time                    `json:"generatedAt"`
+	Clusters    map[string][]clusterLocation `json:"clusters"`
+	Errors      map[string]string            `json:"errors,omitempty"`
+}
+
+var discoverCmd = &cobra.Command{
+	Use:   "discover [cluster-pattern]",
+	Short: "Find which profiles can reach which EKS clusters",
+	Long: `List the EKS clusters of every AWS profile, concurrently, and build an index of cluster to
+profiles. The index is saved to ~/.asp-eks/clusters.json; use --cached to query it without
+calling AWS.
+
+Give a cluster name or pattern (glob, or a regex prefixed with "re:") to answer "where is
+payments-eks-1?". Profiles can be limited with --include and --exclude patterns, and
+--region scans other regions than the profile's own.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		out := cmd.OutOrStdout()
+		if discoverOutput != "text" && discoverOutput != "json" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Unsupported output format %q, use text or json\n", discoverOutput)
+			os.Exit(1)
+		}
+		patterns := append(append([]string{}, discoverInclude...), discoverExclude...)
+		if err := validatePatterns(append(patterns, args...)...); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+			os.Exit(1)
+		}
+
+		var index *clusterIndex
+		var err error
+		if discoverCached {
+			index, err = loadClusterIndex()
+		} else {
+			index, err = discoverClusters(context.Background(), cmd.ErrOrStderr())
+			if err == nil {
+				err = saveClusterIndex(index)
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(out, "Error:", err)
+			return
+		}
+
+		if len(args) == 1 {
+			index = index.Filter(args[0])
+		}
+
+		if discoverOutput == "json" {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(index)
+			return
+		}
+		printClusterIndex(out, index)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(discoverCmd)
+	discoverCmd.Flags().StringArrayVar(&discoverInclude, "include", nil, "Only scan profiles matching this pattern (repeatable)")
+	discoverCmd.Flags().StringArrayVar(&discoverExclude, "exclude", nil, "Skip profiles matching this pattern (repeatable)")
+	discoverCmd.Flags().StringSliceVar(&discoverRegions, "region", nil, "Regions to scan instead of the profile's own region (repeatable or comma separated)")
+	discoverCmd.Flags().IntVar(&discoverConcurrency, "concurrency", 8, "Number of profiles scanned in parallel")
+	discoverCmd.Flags().StringVarP(&discoverOutput, "output", "o", "text", "Output format: text or json")
+	discoverCmd.Flags().BoolVar(&discoverCached, "cached", false, "Use the index of the last discovery instead of calling AWS")
+}
+
+// discoverClusters lists the clusters of every selected profile and region
+func discoverClusters(ctx context.Context, progress io.Writer) (*clusterIndex, error) {
+	allProfiles, err := awsutils.GetAwsProfiles()
+	if err != nil {
+		return nil, err
+	}
+	var profiles []string
+	for _, profile := range allProfiles {
+		if len(discoverInclude) > 0 && !matchAnyPattern(discoverInclude, profile) {
+			continue
+		}
+		if matchAnyPattern(discoverExclude, profile) {
+			continue
+		}
+		profiles = append(profiles, profile)
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no profiles to scan")
+	}
+
+	ensureLogins(profiles, progress)
+
+	regions := discoverRegions
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+	concurrency := discoverConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	type scanResult struct {
+		location clusterLocation
+		clusters []string
+		err      error
+	}
+	var (
+		results []scanResult
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+	slots := make(chan struct{}, concurrency)
+	fmt.Fprintf(progress, "Scanning %d profiles in %d region(s)...\n", len(profiles), len(regions))
+
+	for _, profile := range profiles {
+		for _, region := range regions {
+			wg.Add(1)
+			go func(location clusterLocation) {
+				defer wg.Done()
+				slots <- struct{}{}
+				defer func() { <-slots }()
+
+				clusters, resolvedRegion, err := clusterLister(ctx, location.Profile, location.Region)
+				location.Region = resolvedRegion
+				mu.Lock()
+				results = append(results, scanResult{location: location, clusters: clusters, err: err})
+				mu.Unlock()
+			}(clusterLocation{Profile: profile, Region: region})
+		}
+	}
+	wg.Wait()
+
+	index := &clusterIndex{GeneratedAt: time.Now().UTC(), Clusters: make(map[string][]clusterLocation), Errors: make(map[string]string)}
+	for _, result := range results {
+		if result.err != nil {
+			key := result.location.Profile
+			if result.location.Region != "" {
+				key += " (" + result.location.Region + ")"
+			}
+			index.Errors[key] = result.err.Error()
+			continue
+		}
+		for _, cluster := range result.clusters {
+			index.Clusters[cluster] = append(index.Clusters[cluster], result.location)
+		}
+	}
+	for _, locations := range index.Clusters {
+		sort.Slice(locations, func(i, j int) bool {
+			if locations[i].Profile != locations[j].Profile {
+				return locations[i].Profile < locations[j].Profile
+			}
+			return locations[i].Region < locations[j].Region
+		})
+	}
+	return index, nil
+}
+
+// ensureLogins makes sure every SSO login used by the profiles, directly or at the root of a role
+// chain, is valid before scanning, so the browser login runs at most once per start URL instead
+// of once per profile
+func ensureLogins(profiles []string, progress io.Writer) {
+	checked := make(map[string]bool)
+	for _, profile := range profiles {
+		chain, err := awsutils.GetProfileChain(profile)
+		if err != nil {
+			continue
+		}
+		startURL, _, _, err := awsutils.GetProfileSSOSettings(chain[len(chain)-1].Name)
+		if err != nil || startURL == "" || checked[startURL] {
+			continue
+		}
+		checked[startURL] = true
+
+		originalWriter := outputWriter
+		outputWriter = progress
+		if err := ensureSSO(profile); err != nil {
+			fmt.Fprintf(progress, "Warning: %v\n", err)
+		}
+		outputWriter = originalWriter
+	}
+}
+
+// Filter returns the part of the index whose cluster names match the pattern
+func (index *clusterIndex) Filter(pattern string) *clusterIndex {
+	filtered := &clusterIndex{GeneratedAt: index.GeneratedAt, Clusters: make(map[string][]clusterLocation), Errors: index.Errors}
+	for name, locations := range index.Clusters {
+		if matchPattern(pattern, name) {
+			filtered.Clusters[name] = locations
+		}
+	}
+	return filtered
+}
+
+func printClusterIndex(w io.Writer, index *clusterIndex) {
+	var names []string
+	for name := range index.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		fmt.Fprintln(w, "No clusters found")
+	}
+	for _, name := range names {
+		var locations []string
+		for _, location := range index.Clusters[name] {
+			locations = append(locations, fmt.Sprintf("%s (%s)", location.Profile, location.Region))
+		}
+		fmt.Fprintf(w, "%s: %s\n", name, strings.Join(locations, ", "))
+	}
+
+	if len(index.Errors) > 0 {
+		fmt.Fprintf(w, "\nFailed to scan %d profile(s):\n", len(index.Errors))
+		for _, key := range sortedKeys(index.Errors) {
+			fmt.Fprintf(w, "  %s: %s\n", key, index.Errors[key])
+		}
+	}
+}
+
+func getClusterIndexPath() string {
+	dir := getSettingsDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "clusters.json")
+}
+
+func saveClusterIndex(index *clusterIndex) error {
+	indexPath := getClusterIndexPath()
+	if indexPath == "" {
+		return fmt.Errorf("could not determine cluster index path")
+	}
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(indexPath), err)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(indexPath, append(data, '\n'))
+}
+
+func loadClusterIndex() (*clusterIndex, error) {
+	indexPath := getClusterIndexPath()
+	if indexPath == "" {
+		return nil, fmt.Errorf("could not determine cluster index path")
+	}
+
+	data, err := os.ReadFile(indexPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no cluster index found, run 'asp-eks discover' first")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster index: %w", err)
+	}
+
+	var index clusterIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster index: %w", err)
+	}
+	return &index, nil
+}
+
+// listClustersInRegion lists all clusters of the profile, following pagination, and returns the
+// region that was scanned
+func listClustersInRegion(ctx context.Context, profile, region string) ([]string, string, error) {
+	var optFns []func(*config.LoadOptions) error
+	if region != "" {
+		optFns = append(optFns, config.WithRegion(region))
+	}
+	cfg, err := loadAWSConfig(ctx, profile, optFns...)
+	if err != nil {
+		return nil, region, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, region, fmt.Errorf("no region configured for profile %s", profile)
+	}
+
+	var clusters []string
+	paginator := eks.NewListClustersPaginator(eks.NewFromConfig(cfg), &eks.ListClustersInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, cfg.Region, fmt.Errorf("failed to list EKS clusters: %w", err)
+		}
+		clusters = append(clusters, page.Clusters...)
+	}
+	return clusters, cfg.Region, nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ASP_EKS_HOME", filepath.Join(home, ".asp-eks"))
+
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[profile payments-prod-operator]
+region = eu-west-1
+
+[profile payments-prod-readonly]
+region = eu-west-1
+
+[profile shared-dev]
+region = eu-central-1
+
+[profile broken]
+region = eu-west-1
+
+[profile sandbox]
+region = us-east-1
+`), 0600)
+
+	originalLister := clusterLister
+	defer func() { clusterLister = originalLister }()
+	clusterLister = func(ctx context.Context, profile, region string) ([]string, string, error) {
+		if region == "" {
+			region = "eu-west-1"
+		}
+		switch profile {
+		case "payments-prod-operator", "payments-prod-readonly":
+			return []string{"payments-eks-1", "payments-eks-2"}, region, nil
+		case "shared-dev":
+			return []string{"tools-eks"}, region, nil
+		case "broken":
+			return nil, region, errors.New("access denied")
+		}
+		t.Errorf("Unexpected scan of profile %s", profile)
+		return nil, region, nil
+	}
+
+	var output bytes.Buffer
+	rootCmd.SetOut(&output)
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs([]string{"discover", "--exclude", "sandbox"})
+	defer func() {
+		discoverExclude = nil
+		discoverCached = false
+		rootCmd.SetErr(nil)
+	}()
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := `payments-eks-1: payments-prod-operator (eu-west-1), payments-prod-readonly (eu-west-1)
+payments-eks-2: payments-prod-operator (eu-west-1), payments-prod-readonly (eu-west-1)
+tools-eks: shared-dev (eu-west-1)
+
+Failed to scan 1 profile(s):
+  broken (eu-west-1): access denied
+`
+	if output.String() != expected {
+		t.Errorf("Unexpected output:\n%s", output.String())
+	}
+
+	// The saved index answers without calling AWS
+	clusterLister = func(ctx context.Context, profile, region string) ([]string, string, error) {
+		t.Errorf("Expected no scan with --cached")
+		return nil, region, nil
+	}
+	output.Reset()
+	discoverExclude = nil
+	rootCmd.SetArgs([]string{"discover", "--cached", "payments-eks-1"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(output.String(), "payments-eks-1: payments-prod-operator (eu-west-1), payments-prod-readonly (eu-west-1)\n\n") {
+		t.Errorf("Unexpected cached output:\n%s", output.String())
+	}
+}
+
+func TestDiscoverClustersMultipleRegions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte("[profile shared-dev]\nregion = eu-central-1\n"), 0600)
+
+	originalLister, originalRegions := clusterLister, discoverRegions
+	defer func() { clusterLister, discoverRegions = originalLister, originalRegions }()
+	discoverRegions = []string{"eu-west-1", "us-east-1"}
+	clusterLister = func(ctx context.Context, profile, region string) ([]string, string, error) {
+		if region == "us-east-1" {
+			return []string{"tools-eks"}, region, nil
+		}
+		return nil, region, nil
+	}
+
+	index, err := discoverClusters(context.Background(), &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	locations := index.Clusters["tools-eks"]
+	if len(locations) != 1 || locations[0] != (clusterLocation{Profile: "shared-dev", Region: "us-east-1"}) {
+		t.Errorf("Expected tools-eks in us-east-1, got %v", locations)
+	}
+}
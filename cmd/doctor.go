@@ -0,0 +1,469 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/ini.v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Oldest tool versions asp-eks works with: sso-session sections need AWS CLI 2.9 and the
+// client.authentication.k8s.io/v1beta1 exec plugin written to kubeconfig needs kubectl 1.24
+// (older versions default to v1alpha1 tokens from "aws eks get-token")
+var (
+	minAWSCLIVersion  = []int{2, 9, 0}
+	minKubectlVersion = []int{1, 24, 0}
+)
+
+// Clock skew thresholds: AWS rejects signed requests more than 5 minutes off
+const (
+	clockSkewWarn = time.Minute
+	clockSkewFail = 5 * time.Minute
+)
+
+// doctorStatus is the outcome of a single diagnostic check
+type doctorStatus string
+
+const (
+	doctorPass doctorStatus = "PASS"
+	doctorWarn doctorStatus = "WARN"
+	doctorFail doctorStatus = "FAIL"
+)
+
+// doctorResult is what a check reports, with a remediation hint for warnings and failures
+type doctorResult struct {
+	Check   string
+	Status  doctorStatus
+	Message string
+	Hint    string
+}
+
+// lookPath and toolOutput locate and run external binaries - can be mocked in tests
+var lookPath = exec.LookPath
+var toolOutput = func(name string, args ...string) (string, error) {
+	out, err := execCommand(name, args...).Output()
+	return string(out), err
+}
+
+// serverTimeFetcher returns the time reported by AWS STS - can be mocked in tests
+var serverTimeFetcher = getSTSServerTime
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the AWS, SSO and kubeconfig setup",
+	Long: `Run a series of checks on the local environment: the AWS config file, sso-session references,
+cached SSO tokens, the aws and kubectl binaries, the kubeconfig file, profile regions and the
+clock skew against AWS STS. Every check prints PASS, WARN or FAIL with a hint how to fix it.
+
+Exits with status 1 when any check fails.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		results := runDoctorChecks(context.Background())
+		printDoctorResults(cmd.OutOrStdout(), results)
+
+		for _, result := range results {
+			if result.Status == doctorFail {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// runDoctorChecks runs all checks in order. Checks that need the AWS config file are skipped
+// when it can't be parsed.
+func runDoctorChecks(ctx context.Context) []doctorResult {
+	var results []doctorResult
+
+	awsConfig, configResult := checkAWSConfig()
+	results = append(results, configResult)
+	if awsConfig != nil {
+		results = append(results, checkSSOSessionReferences(awsConfig)...)
+		results = append(results, checkSSOTokens(awsConfig)...)
+		results = append(results, checkProfileRegions(awsConfig))
+	}
+
+	results = append(results,
+		checkToolVersion("aws", []string{"--version"}, parseAWSCLIVersion, minAWSCLIVersion,
+			"install AWS CLI v2: https://docs.aws.amazon.com/cli/latest/userguide/getting-started-install.html"),
+		checkToolVersion("kubectl", []string{"version", "--client", "-o", "json"}, parseKubectlVersion, minKubectlVersion,
+			"install kubectl: https://kubernetes.io/docs/tasks/tools/"),
+		checkKubeConfig(),
+		checkClockSkew(ctx),
+	)
+	return results
+}
+
+func printDoctorResults(w io.Writer, results []doctorResult) {
+	counts := make(map[doctorStatus]int)
+	for _, result := range results {
+		counts[result.Status]++
+		fmt.Fprintf(w, "[%s] %s: %s\n", result.Status, result.Check, result.Message)
+		if result.Status != doctorPass && result.Hint != "" {
+			fmt.Fprintf(w, "       hint: %s\n", result.Hint)
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed\n", counts[doctorPass], counts[doctorWarn], counts[doctorFail])
+}
+
+func checkAWSConfig() (*ini.File, doctorResult) {
+	result := doctorResult{Check: "AWS config"}
+
+	configPath, err := getAwsConfigPath()
+	if err != nil {
+		result.Status, result.Message = doctorFail, err.Error()
+		return nil, result
+	}
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		result.Status, result.Message = doctorFail, configPath+" does not exist"
+		result.Hint = "run 'asp-eks generate-profiles --sso-start-url <url>' or 'aws configure sso'"
+		return nil, result
+	}
+
+	awsConfig, err := ini.Load(configPath)
+	if err != nil {
+		result.Status, result.Message = doctorFail, fmt.Sprintf("failed to parse %s: %v", configPath, err)
+		result.Hint = "fix the syntax error in the file, every key must be inside a [section]"
+		return nil, result
+	}
+
+	profiles := 0
+	for _, section := range awsConfig.Sections() {
+		if strings.HasPrefix(section.Name(), "profile ") || section.Name() == "default" {
+			profiles++
+		}
+	}
+	result.Status, result.Message = doctorPass, fmt.Sprintf("parsed %s (%d profiles)", configPath, profiles)
+	return awsConfig, result
+}
+
+// checkSSOSessionReferences reports profiles whose sso_session points at a missing section
+func checkSSOSessionReferences(awsConfig *ini.File) []doctorResult {
+	var missing []string
+	for _, section := range awsConfig.Sections() {
+		sessionName := section.Key("sso_session").String()
+		if sessionName == "" || awsConfig.HasSection("sso-session "+sessionName) {
+			continue
+		}
+		missing = append(missing, fmt.Sprintf("%s -> %s", strings.TrimPrefix(section.Name(), "profile "), sessionName))
+	}
+
+	if len(missing) > 0 {
+		return []doctorResult{{
+			Check:   "SSO sessions",
+			Status:  doctorFail,
+			Message: "profiles reference missing sso-sessions: " + strings.Join(missing, ", "),
+			Hint:    "add the [sso-session <name>] sections or run 'asp-eks generate-profiles' again",
+		}}
+	}
+	return []doctorResult{{Check: "SSO sessions", Status: doctorPass, Message: "all sso_session references exist"}}
+}
+
+// checkSSOTokens looks for a usable cached token for every SSO start URL in the config
+func checkSSOTokens(awsConfig *ini.File) []doctorResult {
+	// Start URL to the sso-session using it, empty for legacy profiles with their own sso_start_url
+	startURLs := make(map[string]string)
+	for _, section := range awsConfig.Sections() {
+		startURL := section.Key("sso_start_url").String()
+		if startURL == "" {
+			continue
+		}
+		if sessionName, ok := strings.CutPrefix(section.Name(), "sso-session "); ok {
+			startURLs[startURL] = sessionName
+		} else if _, seen := startURLs[startURL]; !seen {
+			startURLs[startURL] = ""
+		}
+	}
+
+	if len(startURLs) == 0 {
+		return []doctorResult{{Check: "SSO token", Status: doctorWarn, Message: "no SSO start URL configured",
+			Hint: "run 'asp-eks generate-profiles --sso-start-url <url>'"}}
+	}
+
+	var urls []string
+	for startURL := range startURLs {
+		urls = append(urls, startURL)
+	}
+	sort.Strings(urls)
+
+	var results []doctorResult
+	for _, startURL := range urls {
+		result := doctorResult{Check: "SSO token " + startURL}
+		loginHint := "run 'aws sso login --profile <profile>'"
+		if sessionName := startURLs[startURL]; sessionName != "" {
+			loginHint = fmt.Sprintf("run 'aws sso login --sso-session %s'", sessionName)
+		}
+
+		token, err := findSSOCacheToken(startURL)
+		switch {
+		case err != nil:
+			result.Status, result.Message, result.Hint = doctorFail, "no cached token", loginHint
+		case time.Now().Before(token.ExpiresAt):
+			result.Status = doctorPass
+			result.Message = fmt.Sprintf("valid for %s", time.Until(token.ExpiresAt).Round(time.Minute))
+		case token.CanRefresh():
+			result.Status, result.Message = doctorWarn, "expired, will be refreshed with the cached refresh token"
+			result.Hint = loginHint + " if the refresh fails"
+		default:
+			result.Status = doctorFail
+			result.Message = "expired at " + token.ExpiresAt.Local().Format(time.RFC1123)
+			result.Hint = loginHint
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkProfileRegions reports profiles without a region, unless one is set in the environment
+func checkProfileRegions(awsConfig *ini.File) doctorResult {
+	result := doctorResult{Check: "Profile regions"}
+
+	var missing []string
+	for _, section := range awsConfig.Sections() {
+		name, ok := strings.CutPrefix(section.Name(), "profile ")
+		if !ok {
+			if section.Name() != "default" || len(section.Keys()) == 0 {
+				continue
+			}
+			name = "default"
+		}
+		if section.Key("region").String() == "" {
+			missing = append(missing, name)
+		}
+	}
+
+	switch {
+	case len(missing) == 0:
+		result.Status, result.Message = doctorPass, "every profile has a region"
+	case os.Getenv("AWS_REGION") != "" || os.Getenv("AWS_DEFAULT_REGION") != "":
+		result.Status = doctorPass
+		result.Message = fmt.Sprintf("%d profiles without region use the region from the environment", len(missing))
+	default:
+		result.Status = doctorWarn
+		result.Message = "profiles without region: " + strings.Join(missing, ", ")
+		result.Hint = "add 'region = <region>' to the profiles or regenerate them with --region-map or --detect-region"
+	}
+	return result
+}
+
+// checkToolVersion verifies a binary is on the PATH and not older than minVersion
+func checkToolVersion(name string, versionArgs []string, parse func(string) ([]int, bool), minVersion []int, installHint string) doctorResult {
+	result := doctorResult{Check: name}
+
+	binaryPath, err := lookPath(name)
+	if err != nil {
+		result.Status, result.Message, result.Hint = doctorFail, "not found in PATH", installHint
+		return result
+	}
+
+	out, err := toolOutput(name, versionArgs...)
+	if err != nil {
+		result.Status, result.Message = doctorWarn, fmt.Sprintf("found %s but failed to get its version: %v", binaryPath, err)
+		result.Hint = installHint
+		return result
+	}
+	version, ok := parse(out)
+	if !ok {
+		result.Status, result.Message = doctorWarn, fmt.Sprintf("found %s but could not parse its version", binaryPath)
+		return result
+	}
+
+	if compareVersions(version, minVersion) < 0 {
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("version %s is older than the required %s", formatVersion(version), formatVersion(minVersion))
+		result.Hint = "upgrade " + name + ", " + installHint
+		return result
+	}
+	result.Status, result.Message = doctorPass, fmt.Sprintf("version %s (%s)", formatVersion(version), binaryPath)
+	return result
+}
+
+var (
+	awsCLIVersionRegexp  = regexp.MustCompile(`aws-cli/(\d+)\.(\d+)\.(\d+)`)
+	kubectlVersionRegexp = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)`)
+)
+
+// parseAWSCLIVersion parses output like "aws-cli/2.15.30 Python/3.11.8 Darwin/23.4.0 ..."
+func parseAWSCLIVersion(out string) ([]int, bool) {
+	return versionFromMatch(awsCLIVersionRegexp.FindStringSubmatch(out))
+}
+
+// parseKubectlVersion parses the JSON output of "kubectl version --client -o json"
+func parseKubectlVersion(out string) ([]int, bool) {
+	var version struct {
+		ClientVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}
+	if err := json.Unmarshal([]byte(out), &version); err != nil {
+		return nil, false
+	}
+	return versionFromMatch(kubectlVersionRegexp.FindStringSubmatch(version.ClientVersion.GitVersion))
+}
+
+func versionFromMatch(match []string) ([]int, bool) {
+	if match == nil {
+		return nil, false
+	}
+	version := make([]int, 0, len(match)-1)
+	for _, part := range match[1:] {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		version = append(version, n)
+	}
+	return version, true
+}
+
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+func formatVersion(version []int) string {
+	parts := make([]string, len(version))
+	for i, n := range version {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// checkKubeConfig verifies the kubeconfig asp-eks writes to can be parsed and written
+func checkKubeConfig() doctorResult {
+	result := doctorResult{Check: "kubeconfig"}
+
+	configPath := getDefaultKubeConfigPath()
+	if configPath == "" {
+		result.Status, result.Message = doctorFail, "could not determine kubeconfig path"
+		result.Hint = "make sure HOME is set"
+		return result
+	}
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if err := checkDirWritable(filepath.Dir(configPath)); err != nil {
+			result.Status, result.Message = doctorFail, fmt.Sprintf("%s does not exist and can't be created: %v", configPath, err)
+			result.Hint = "check the permissions of " + filepath.Dir(configPath)
+			return result
+		}
+		result.Status, result.Message = doctorPass, configPath+" does not exist yet and will be created"
+		return result
+	}
+
+	if _, err := clientcmd.LoadFromFile(configPath); err != nil {
+		result.Status, result.Message = doctorFail, fmt.Sprintf("failed to parse %s: %v", configPath, err)
+		result.Hint = "fix or move away the broken file, 'kubectl config view' shows the problem"
+		return result
+	}
+
+	file, err := os.OpenFile(configPath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		result.Status, result.Message = doctorFail, fmt.Sprintf("%s is not writable: %v", configPath, err)
+		result.Hint = "fix the permissions with 'chmod u+w " + configPath + "'"
+		return result
+	}
+	file.Close()
+
+	result.Status, result.Message = doctorPass, configPath+" is valid and writable"
+	if kubeconfigEnv := os.Getenv("KUBECONFIG"); kubeconfigEnv != "" && kubeconfigEnv != configPath {
+		result.Status = doctorWarn
+		result.Message += fmt.Sprintf(", but KUBECONFIG is set to %s", kubeconfigEnv)
+		result.Hint = "asp-eks writes to " + configPath + ", include it in KUBECONFIG or unset the variable"
+	}
+	return result
+}
+
+func checkDirWritable(dir string) error {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			tempFile, err := os.CreateTemp(dir, ".asp-eks-doctor-")
+			if err != nil {
+				return err
+			}
+			tempFile.Close()
+			return os.Remove(tempFile.Name())
+		}
+		if !os.IsNotExist(err) || filepath.Dir(dir) == dir {
+			return err
+		}
+		// The directory will be created, check its closest existing parent
+		dir = filepath.Dir(dir)
+	}
+}
+
+// checkClockSkew compares the local clock with the Date header returned by AWS STS
+func checkClockSkew(ctx context.Context) doctorResult {
+	result := doctorResult{Check: "Clock skew"}
+
+	before := time.Now()
+	serverTime, err := serverTimeFetcher(ctx)
+	if err != nil {
+		result.Status, result.Message = doctorWarn, fmt.Sprintf("could not reach AWS STS: %v", err)
+		result.Hint = "check your network connection and proxy settings"
+		return result
+	}
+	// The Date header has second precision, compare against the middle of the request
+	localTime := before.Add(time.Since(before) / 2)
+	skew := localTime.Sub(serverTime)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	switch {
+	case skew >= clockSkewFail:
+		result.Status = doctorFail
+	case skew >= clockSkewWarn:
+		result.Status = doctorWarn
+	default:
+		result.Status = doctorPass
+	}
+	result.Message = fmt.Sprintf("local clock is %s off", skew.Round(time.Second))
+	if result.Status != doctorPass {
+		result.Hint = "enable time synchronisation (NTP), AWS rejects requests signed more than 5 minutes off"
+	}
+	return result
+}
+
+func getSTSServerTime(ctx context.Context) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "https://sts.amazonaws.com/", nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp.Body.Close()
+
+	return http.ParseTime(resp.Header.Get("Date"))
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunDoctorChecks(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KUBECONFIG", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[sso-session acme]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = eu-central-1
+
+[sso-session legacy]
+sso_start_url = https://legacy.awsapps.com/start
+sso_region = eu-central-1
+
+[profile payments-prod]
+sso_session = acme
+sso_account_id = 123456789012
+sso_role_name = operator
+region = eu-west-1
+
+[profile payments-dev]
+sso_session = acme
+sso_account_id = 210987654321
+sso_role_name = operator
+
+[profile orphan]
+sso_session = missing
+`), 0600)
+	writeSSOCacheFile(t, home, "acme.json", map[string]interface{}{
+		"startUrl":    "https://acme.awsapps.com/start",
+		"accessToken": "token",
+		"expiresAt":   time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+
+	originalLookPath, originalToolOutput, originalServerTime := lookPath, toolOutput, serverTimeFetcher
+	defer func() {
+		lookPath, toolOutput, serverTimeFetcher = originalLookPath, originalToolOutput, originalServerTime
+	}()
+	lookPath = func(name string) (string, error) {
+		if name == "kubectl" {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/local/bin/" + name, nil
+	}
+	toolOutput = func(name string, args ...string) (string, error) {
+		return "aws-cli/2.8.1 Python/3.9.11 Linux/6.1.0 exe/x86_64.debian.12\n", nil
+	}
+	serverTimeFetcher = func(ctx context.Context) (time.Time, error) {
+		return time.Now().Add(-2 * time.Minute), nil
+	}
+
+	statuses := make(map[string]doctorStatus)
+	messages := make(map[string]string)
+	for _, result := range runDoctorChecks(context.Background()) {
+		statuses[result.Check] = result.Status
+		messages[result.Check] = result.Message
+	}
+
+	expected := map[string]doctorStatus{
+		"AWS config":   doctorPass,
+		"SSO sessions": doctorFail,
+		"SSO token https://acme.awsapps.com/start":   doctorPass,
+		"SSO token https://legacy.awsapps.com/start": doctorFail,
+		"Profile regions": doctorWarn,
+		"aws":             doctorFail,
+		"kubectl":         doctorFail,
+		"kubeconfig":      doctorPass,
+		"Clock skew":      doctorWarn,
+	}
+	for check, status := range expected {
+		if statuses[check] != status {
+			t.Errorf("Expected %s to be %s, got %s (%s)", check, status, statuses[check], messages[check])
+		}
+	}
+	if !strings.Contains(messages["SSO sessions"], "orphan -> missing") {
+		t.Errorf("Expected the orphan profile to be reported, got %s", messages["SSO sessions"])
+	}
+	if messages["Profile regions"] != "profiles without region: payments-dev, orphan" {
+		t.Errorf("Unexpected profile regions message: %s", messages["Profile regions"])
+	}
+}
+
+func TestCheckClockSkewUnreachable(t *testing.T) {
+	originalServerTime := serverTimeFetcher
+	defer func() { serverTimeFetcher = originalServerTime }()
+	serverTimeFetcher = func(ctx context.Context) (time.Time, error) {
+		return time.Time{}, errors.New("dial tcp: no route to host")
+	}
+
+	result := checkClockSkew(context.Background())
+	if result.Status != doctorWarn || result.Hint == "" {
+		t.Errorf("Expected a warning with a hint, got %+v", result)
+	}
+}
+
+func TestParseToolVersions(t *testing.T) {
+	if version, ok := parseAWSCLIVersion("aws-cli/2.15.30 Python/3.11.8 Darwin/23.4.0 source/arm64"); !ok || formatVersion(version) != "2.15.30" {
+		t.Errorf("Unexpected AWS CLI version %v", version)
+	}
+	if version, ok := parseKubectlVersion(`{"clientVersion": {"gitVersion": "v1.29.2"}}`); !ok || formatVersion(version) != "1.29.2" {
+		t.Errorf("Unexpected kubectl version %v", version)
+	}
+	if compareVersions([]int{1, 29, 2}, minKubectlVersion) < 0 || compareVersions([]int{1, 23, 9}, minKubectlVersion) >= 0 {
+		t.Error("Unexpected version comparison result")
+	}
+}
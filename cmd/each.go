@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/eimarfandino/asp-eks/awsutils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	eachProfiles    []string
+	eachClusters    []string
+	eachConcurrency int
+)
+
+// eachTarget is a cluster the command runs against, with the profile used to reach it
+type eachTarget struct {
+	Profile     string
+	ClusterInfo *ClusterInfo
+}
+
+// eachResult is the outcome of running the command against one target
+type eachResult struct {
+	Context  string
+	Profile  string
+	ExitCode int
+	Err      error
+}
+
+var eachCmd = &cobra.Command{
+	Use:   "each --profiles <pattern> [--clusters <pattern>] -- <command> [args...]",
+	Short: "Run a command against every cluster of a set of profiles",
+	Long: `Resolve the clusters of all profiles matching --profiles (and --clusters, when given) and run the
+command once per cluster, concurrently, each with its own temporary kubeconfig, AWS_PROFILE and
+AWS_REGION. Output lines are prefixed with the context name and a summary of exit codes is
+printed at the end. Clusters reachable through several profiles run once, with the first
+matching profile.
+
+Exits with status 1 when the command failed for any cluster.
+
+  asp-eks each --profiles 'prod-*' -- kubectl get nodes`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() != 0 || len(args) == 0 {
+			return errors.New("expected -- <command> [args...]")
+		}
+		if len(eachProfiles) == 0 {
+			return errors.New("--profiles is required")
+		}
+		if err := validatePatterns(eachProfiles...); err != nil {
+			return err
+		}
+		return validatePatterns(eachClusters...)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		originalWriter := outputWriter
+		outputWriter = cmd.ErrOrStderr()
+		defer func() { outputWriter = originalWriter }()
+
+		results, err := runEach(context.Background(), cmd.OutOrStdout(), args)
+		if err != nil {
+			fmt.Fprintln(outputWriter, "Error:", err)
+			os.Exit(1)
+		}
+
+		if printEachSummary(cmd.OutOrStdout(), results) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(eachCmd)
+	eachCmd.Flags().StringArrayVar(&eachProfiles, "profiles", nil, "Profiles to run against, glob or re: pattern (repeatable)")
+	eachCmd.Flags().StringArrayVar(&eachClusters, "clusters", nil, "Only run against clusters matching this pattern (repeatable)")
+	eachCmd.Flags().IntVar(&eachConcurrency, "concurrency", 4, "Number of clusters the command runs against in parallel")
+}
+
+// runEach resolves the targets and runs the command against each of them
+func runEach(ctx context.Context, out io.Writer, command []string) ([]eachResult, error) {
+	allProfiles, err := awsutils.GetAwsProfiles()
+	if err != nil {
+		return nil, err
+	}
+	var profiles []string
+	for _, profile := range allProfiles {
+		if matchAnyPattern(eachProfiles, profile) {
+			profiles = append(profiles, profile)
+		}
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no profiles match %v", eachProfiles)
+	}
+	ensureLogins(profiles, outputWriter)
+
+	targets, results := resolveEachTargets(ctx, profiles)
+	if len(targets) == 0 && len(results) == 0 {
+		return nil, fmt.Errorf("no clusters found")
+	}
+
+	tempDir, err := os.MkdirTemp("", "asp-eks-each-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	concurrency := eachConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		mu    sync.Mutex
+		outMu sync.Mutex
+		wg    sync.WaitGroup
+	)
+	slots := make(chan struct{}, concurrency)
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target eachTarget) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			result := eachResult{Context: target.ClusterInfo.Name, Profile: target.Profile}
+			kubeConfigPath := filepath.Join(tempDir, strconv.Itoa(i), "config")
+			if err := writeKubeContext(kubeConfigPath, target.ClusterInfo); err != nil {
+				result.Err = err
+			} else {
+				result.ExitCode, result.Err = runPrefixed(out, &outMu, target, kubeConfigPath, command)
+			}
+
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(i, target)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Context < results[j].Context })
+	return results, nil
+}
+
+// resolveEachTargets lists and describes the clusters of the profiles. Profiles or clusters that
+// fail are returned as failed results.
+func resolveEachTargets(ctx context.Context, profiles []string) ([]eachTarget, []eachResult) {
+	var targets []eachTarget
+	var failed []eachResult
+	seen := make(map[string]bool)
+
+	for _, profile := range profiles {
+		clusters, err := clusterProvider.ListClusters(ctx, profile)
+		if err != nil {
+			failed = append(failed, eachResult{Context: "(" + profile + ")", Profile: profile, Err: err})
+			continue
+		}
+		for _, cluster := range clusters {
+			if len(eachClusters) > 0 && !matchAnyPattern(eachClusters, cluster) {
+				continue
+			}
+			clusterInfo, err := clusterProvider.GetClusterInfo(ctx, profile, cluster)
+			if err == nil {
+				clusterInfo, err = withRoleOverride(profile, clusterInfo, "")
+			}
+			if err != nil {
+				failed = append(failed, eachResult{Context: cluster, Profile: profile, Err: err})
+				continue
+			}
+			if seen[clusterInfo.Arn] {
+				continue
+			}
+			seen[clusterInfo.Arn] = true
+			targets = append(targets, eachTarget{Profile: profile, ClusterInfo: clusterInfo})
+		}
+	}
+	return targets, failed
+}
+
+// runPrefixed runs the command for a target, writing its output line by line prefixed with the
+// context name
+func runPrefixed(out io.Writer, outMu *sync.Mutex, target eachTarget, kubeConfigPath string, command []string) (int, error) {
+	prefix := "[" + target.ClusterInfo.Name + "] "
+	stdout := &prefixWriter{out: out, mu: outMu, prefix: prefix}
+	stderr := &prefixWriter{out: outputWriter, mu: outMu, prefix: prefix}
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	process := execCommand(command[0], command[1:]...)
+	process.Env = clusterEnv(process.Env, target.Profile, target.ClusterInfo.Region, kubeConfigPath)
+	process.Stdout = stdout
+	process.Stderr = stderr
+
+	err := process.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// printEachSummary prints the exit code per context and reports whether any of them failed
+func printEachSummary(w io.Writer, results []eachResult) bool {
+	failed := 0
+	fmt.Fprintln(w, "\nSummary:")
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Fprintf(w, "  %-40s error: %v\n", result.Context, result.Err)
+		case result.ExitCode != 0:
+			failed++
+			fmt.Fprintf(w, "  %-40s exit code %d\n", result.Context, result.ExitCode)
+		default:
+			fmt.Fprintf(w, "  %-40s ok\n", result.Context)
+		}
+	}
+	fmt.Fprintf(w, "%d succeeded, %d failed\n", len(results)-failed, failed)
+	return failed > 0
+}
+
+// prefixWriter writes complete lines to out with a prefix, so output of concurrent commands
+// doesn't interleave within a line
+type prefixWriter struct {
+	out    io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes a last line that doesn't end with a newline
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, "%s%s", w.prefix, line)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// eachClusterProvider serves different clusters per profile
+type eachClusterProvider struct {
+	mockClusterProvider
+	clustersByProfile map[string][]string
+}
+
+func (p *eachClusterProvider) ListClusters(ctx context.Context, profile string) ([]string, error) {
+	clusters, ok := p.clustersByProfile[profile]
+	if !ok {
+		return nil, fmt.Errorf("access denied")
+	}
+	return clusters, nil
+}
+
+func TestRunEach(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[profile prod-payments]
+region = eu-west-1
+
+[profile prod-shared]
+region = eu-west-1
+
+[profile prod-broken]
+region = eu-west-1
+
+[profile dev-payments]
+region = eu-west-1
+`), 0600)
+
+	originalProvider := clusterProvider
+	clusterProvider = &eachClusterProvider{
+		mockClusterProvider: mockClusterProvider{region: "eu-west-1"},
+		clustersByProfile: map[string][]string{
+			"prod-payments": {"payments-eks-1", "failing-eks"},
+			"prod-shared":   {"tools-eks", "legacy-eks"},
+			"dev-payments":  {"dev-eks"},
+		},
+	}
+	defer func() { clusterProvider = originalProvider }()
+
+	execCommand = fakeExecCommand
+	defer func() { execCommand = exec.Command }()
+
+	var errOutput bytes.Buffer
+	outputWriter = &errOutput
+	defer func() { outputWriter = os.Stdout }()
+
+	eachProfiles = []string{"prod-*"}
+	eachClusters = []string{"*-eks-1", "failing-*", "tools-*"}
+	defer func() { eachProfiles, eachClusters = nil, nil }()
+
+	var output bytes.Buffer
+	results, err := runEach(context.Background(), &output, []string{"cluster-check"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var summary bytes.Buffer
+	if !printEachSummary(&summary, results) {
+		t.Error("Expected the summary to report failures")
+	}
+	lines := strings.Split(strings.TrimSpace(summary.String()), "\n")
+	if len(lines) != 6 || !strings.Contains(lines[1], "(prod-broken)") || !strings.Contains(lines[1], "error: access denied") ||
+		!strings.Contains(lines[2], "failing-eks") || !strings.Contains(lines[2], "exit code 3") ||
+		!strings.HasSuffix(lines[3], "ok") || !strings.HasSuffix(lines[4], "ok") || lines[5] != "2 succeeded, 2 failed" {
+		t.Errorf("Unexpected summary:\n%s", summary.String())
+	}
+
+	for _, line := range []string{"[payments-eks-1] check passed\n", "[tools-eks] check passed\n"} {
+		if !strings.Contains(output.String(), line) {
+			t.Errorf("Expected output line %q, got:\n%s", line, output.String())
+		}
+	}
+	if strings.Contains(output.String(), "legacy-eks") || strings.Contains(output.String(), "dev-eks") {
+		t.Errorf("Expected only matching profiles and clusters, got:\n%s", output.String())
+	}
+	if !strings.Contains(errOutput.String(), "[failing-eks] check failed\n") {
+		t.Errorf("Expected prefixed stderr of the failing cluster, got:\n%s", errOutput.String())
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := &prefixWriter{out: &out, mu: &sync.Mutex{}, prefix: "[a] "}
+	w.Write([]byte("one\ntw"))
+	w.Write([]byte("o\nthree"))
+	w.Flush()
+	if out.String() != "[a] one\n[a] two\n[a] three\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <profile[:cluster]|alias> -- <command> [args...]",
+	Short: "Run a command with the credentials and kubeconfig of a profile and cluster",
+	Long: `Ensure the SSO login of the profile, write a temporary kubeconfig for the cluster and run the
+command with AWS_PROFILE, AWS_REGION and KUBECONFIG set. Inherited static credentials
+(AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN) and AWS_DEFAULT_REGION are
+removed so they can't override the profile. The global kubeconfig and its current context are
+not touched, so scripts can run helm or terraform against one cluster while the interactive
+shell stays on another.
+
+Without a cluster the profile must see exactly one cluster. The exit code of the command is
+passed through.
+
+  asp-eks exec payments-prod-operator:payments-eks-1 -- helm list -A`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() != 1 || len(args) < 2 {
+			return errors.New("expected <profile[:cluster]> -- <command> [args...]")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// Keep stdout for the command, asp-eks messages go to stderr
+		originalWriter := outputWriter
+		outputWriter = cmd.ErrOrStderr()
+		defer func() { outputWriter = originalWriter }()
+
+		exitCode, err := runWithClusterContext(context.Background(), cmd, args[0], args[1:])
+		if err != nil {
+			fmt.Fprintln(outputWriter, "Error:", err)
+			exitCode = 1
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+}
+
+// runWithClusterContext runs the command against the target and returns its exit code
+func runWithClusterContext(ctx context.Context, cmd *cobra.Command, target string, command []string) (int, error) {
+	settings, err := loadSettings()
+	if err != nil {
+		return 0, err
+	}
+	profile, cluster := resolveTarget(loadAliases(settings), target)
+
+	if err := ensureSSO(profile); err != nil {
+		return 0, fmt.Errorf("failed to ensure SSO login: %w", err)
+	}
+	clusterInfo, err := resolveClusterInfo(ctx, profile, cluster, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "asp-eks-exec-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	kubeConfigPath := filepath.Join(tempDir, "config")
+	if err := writeKubeContext(kubeConfigPath, clusterInfo); err != nil {
+		return 0, err
+	}
+
+	process := execCommand(command[0], command[1:]...)
+	process.Env = clusterEnv(process.Env, profile, clusterInfo.Region, kubeConfigPath)
+	process.Stdin = os.Stdin
+	process.Stdout = cmd.OutOrStdout()
+	process.Stderr = cmd.ErrOrStderr()
+
+	err = runInForeground(process)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to run %s: %w", command[0], err)
+	}
+	return 0, nil
+}
+
+// resolveClusterInfo describes the cluster, with the role configured for it in [roles]. When no
+// cluster is given the profile's only cluster is used; if there are several, pick chooses one
+// or, when nil, an error is returned.
+func resolveClusterInfo(ctx context.Context, profile, cluster string, pick func(clusters []string) (string, error)) (*ClusterInfo, error) {
+	if cluster == "" {
+		clusters, err := clusterProvider.ListClusters(ctx, profile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list clusters: %w", err)
+		}
+		switch len(clusters) {
+		case 0:
+			return nil, fmt.Errorf("no clusters found for profile %s", profile)
+		case 1:
+			cluster = clusters[0]
+		default:
+			if pick == nil {
+				return nil, fmt.Errorf("profile %s has %d clusters, use %s:<cluster> with one of: %v", profile, len(clusters), profile, clusters)
+			}
+			if cluster, err = pick(clusters); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	clusterInfo, err := clusterProvider.GetClusterInfo(ctx, profile, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster info: %w", err)
+	}
+	return withRoleOverride(profile, clusterInfo, "")
+}
+
+// runInForeground runs an interactive process that shares the terminal with asp-eks. The terminal
+// sends Ctrl-C and Ctrl-\ to its whole foreground process group, so the process gets them on its
+// own; asp-eks catches and drops them meanwhile instead of dying and leaving the process and its
+// temporary kubeconfig behind. Caught signals are reset to their default in the process, unlike
+// ignored ones, so Ctrl-C keeps working inside it.
+func runInForeground(process *exec.Cmd) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+	return process.Run()
+}
+
+// inheritedAWSVariables are dropped from the environment of commands run against a cluster. Static
+// credentials take precedence over AWS_PROFILE and AWS_DEFAULT_REGION could point tools at
+// another region, both of which would silently target something else than the chosen cluster.
+var inheritedAWSVariables = []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_DEFAULT_REGION"}
+
+// clusterEnv returns env (the current environment when nil) with the variables pointing tools
+// at the profile, region and kubeconfig
+func clusterEnv(env []string, profile, region, kubeConfigPath string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+	var filtered []string
+	for _, variable := range env {
+		name, _, _ := strings.Cut(variable, "=")
+		if !slices.Contains(inheritedAWSVariables, name) {
+			filtered = append(filtered, variable)
+		}
+	}
+	return append(filtered,
+		"AWS_PROFILE="+profile,
+		"AWS_REGION="+region,
+		"KUBECONFIG="+kubeConfigPath,
+	)
+}
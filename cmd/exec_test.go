@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+
+	globalConfig := filepath.Join(home, ".kube", "config")
+	os.MkdirAll(filepath.Dir(globalConfig), 0755)
+	os.WriteFile(globalConfig, []byte(testKubeConfig), 0600)
+
+	originalProvider := clusterProvider
+	clusterProvider = &mockClusterProvider{region: "eu-west-1", clusters: []string{"payments-eks-1"}}
+	defer func() { clusterProvider = originalProvider }()
+
+	originalCredentialsValidator := credentialsValidator
+	credentialsValidator = func(ctx context.Context, profile string) bool { return true }
+	defer func() { credentialsValidator = originalCredentialsValidator }()
+
+	execCommand = fakeExecCommand
+	defer func() { execCommand = exec.Command }()
+
+	var output, errOutput bytes.Buffer
+	rootCmd.SetOut(&output)
+	rootCmd.SetErr(&errOutput)
+	defer rootCmd.SetErr(nil)
+	rootCmd.SetArgs([]string{"exec", "payments-prod-operator", "--", "print-env"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got := output.String()
+	if !strings.HasPrefix(got, "AWS_PROFILE=payments-prod-operator AWS_REGION=eu-west-1\n") {
+		t.Errorf("Expected profile and region in the environment, got: %s", got)
+	}
+	if !strings.Contains(got, "current-context: payments-eks-1") {
+		t.Errorf("Expected a kubeconfig for payments-eks-1, got: %s", got)
+	}
+	if !strings.Contains(errOutput.String(), "Credentials are valid") {
+		t.Errorf("Expected asp-eks messages on stderr, got: %s", errOutput.String())
+	}
+
+	data, _ := os.ReadFile(globalConfig)
+	if string(data) != testKubeConfig {
+		t.Errorf("Expected the global kubeconfig to be untouched, got:\n%s", data)
+	}
+}
+
+func TestRunWithClusterContextExitCode(t *testing.T) {
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+
+	originalProvider := clusterProvider
+	clusterProvider = &mockClusterProvider{region: "eu-west-1", clusters: []string{"one", "two"}}
+	defer func() { clusterProvider = originalProvider }()
+
+	originalCredentialsValidator := credentialsValidator
+	credentialsValidator = func(ctx context.Context, profile string) bool { return true }
+	defer func() { credentialsValidator = originalCredentialsValidator }()
+
+	execCommand = fakeExecCommand
+	defer func() { execCommand = exec.Command }()
+
+	var output bytes.Buffer
+	outputWriter = &output
+	defer func() { outputWriter = os.Stdout }()
+	rootCmd.SetOut(&output)
+	rootCmd.SetErr(&output)
+	defer rootCmd.SetErr(nil)
+
+	if _, err := runWithClusterContext(context.Background(), execCmd, "payments-prod-operator", []string{"exit-3"}); err == nil ||
+		!strings.Contains(err.Error(), "has 2 clusters") {
+		t.Errorf("Expected an error asking for the cluster, got %v", err)
+	}
+
+	exitCode, err := runWithClusterContext(context.Background(), execCmd, "payments-prod-operator:two", []string{"exit-3"})
+	if err != nil || exitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d (%v)", exitCode, err)
+	}
+}
+
+func TestClusterEnvDropsInheritedCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+	t.Setenv("AWS_DEFAULT_REGION", "us-east-1")
+	t.Setenv("AWS_PROFILE", "other")
+	t.Setenv("ASP_EKS_TEST_VARIABLE", "kept")
+
+	env := clusterEnv(nil, "payments-prod-operator", "eu-west-1", "/tmp/kubeconfig")
+
+	// Like os/exec, the last value of a variable wins
+	values := make(map[string]string)
+	for _, variable := range env {
+		name, value, _ := strings.Cut(variable, "=")
+		values[name] = value
+	}
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_DEFAULT_REGION"} {
+		if value, ok := values[name]; ok {
+			t.Errorf("Expected %s to be dropped, got %q", name, value)
+		}
+	}
+	if values["AWS_PROFILE"] != "payments-prod-operator" || values["AWS_REGION"] != "eu-west-1" ||
+		values["KUBECONFIG"] != "/tmp/kubeconfig" || values["ASP_EKS_TEST_VARIABLE"] != "kept" {
+		t.Errorf("Unexpected environment %v", env)
+	}
+}
+
+func TestRunInForegroundSurvivesInterrupt(t *testing.T) {
+	// The child interrupts the test process, like Ctrl-C at a prompt would, and still finishes
+	process := exec.Command("/bin/sh", "-c", "kill -INT $PPID; sleep 0.2; exit 3")
+	err := runInForeground(process)
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("Expected the child to finish with exit code 3, got %v", err)
+	}
+}
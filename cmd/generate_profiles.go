@@ -2,13 +2,12 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
-	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -26,6 +25,7 @@ func configureIniFormatting() {
 var (
 	defaultRegion   string
 	dryRun          bool
+	forceFlag       bool
 	ssoStartURLFlag string
 )
 
@@ -34,17 +34,44 @@ var generateProfilesCmd = &cobra.Command{
 	Short: "Generate AWS profiles for all SSO accounts and roles",
 	Long: `Generate AWS profiles for all SSO accounts and roles accessible to your user.
 This command will:
-1. Create default SSO configuration if not present called "DEFAULT-SSO"
+1. Create default SSO configuration if not present called "DEFAULT-SSO" (see --sso-profile and --sso-session)
 2. Query AWS SSO to get all accounts and roles available to you
 3. Generate AWS CLI profiles for each account/role combination
-4. Write them to ~/.aws/config
+4. Write them to a managed block of ~/.aws/config, replacing the profiles of the previous run
+
+Hand-edited sections outside the managed block are never modified. If a generated profile has
+the same name as a hand-written one, the command refuses to write unless --force is given.
 
 The profiles will be named in the format: <account-alias>-<role-name> or <account-id>-<role-name> if no alias is available.
 
+Accounts and roles can be narrowed down with the --include-*/--exclude-* flags or the
+[filters] section of ~/.asp-eks/config, for example:
+
+  [filters]
+  include_account_names = team-*, re:^shared-(dev|prod)$
+  exclude_roles = *readonly*
+
+Profiles use the --region value unless a region rule matches the account ID or name.
+Rules come from --region-map flags or the [regions] section of ~/.asp-eks/config:
+
+  [regions]
+  123456789012 = us-east-1
+  team-a-* = eu-west-1
+
+With --detect-region, accounts without a rule are probed for EKS clusters and get the
+first region in which clusters are found.
+
 Prerequisites:
-- You must be logged in to AWS SSO (run 'aws sso login --profile DEFAULT-SSO' after first run)`,
+- You must be logged in to AWS SSO (run 'aws sso login --profile DEFAULT-SSO' after first run)
+
+The base profile, sso-session and role can be configured with --sso-profile, --sso-session and
+--sso-role or in the [sso] section of ~/.asp-eks/config (profile_name, session_name, role_name).
+When no role is configured you are asked to pick one of your roles after login.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := generateProfiles(); err != nil {
+			if errors.Is(err, errProfilesChanged) {
+				os.Exit(2)
+			}
 			fmt.Fprintf(cmd.ErrOrStderr(), "Error generating profiles: %v\n", err)
 			os.Exit(1)
 		}
@@ -56,18 +83,18 @@ type AccountRole struct {
 	AccountName  string
 	RoleName     string
 	EmailAddress string
-}
-
-type SSOCacheToken struct {
-	AccessToken string    `json:"accessToken"`
-	ExpiresAt   time.Time `json:"expiresAt"`
-	Region      string    `json:"region"`
-	StartURL    string    `json:"startUrl"`
+	Region       string
 }
 
 func generateProfiles() error {
 	ctx := context.Background()
 
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	base := loadBaseSSOConfig(settings, baseSSOFlags)
+
 	// Load AWS config to get SSO configuration
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
@@ -85,25 +112,11 @@ func generateProfiles() error {
 			configPath := filepath.Join(homeDir, ".aws", "config")
 			if _, statErr := os.Stat(configPath); os.IsNotExist(statErr) {
 				// Create minimal config file using the provided SSO start URL
-				cfg := ini.Empty()
-				cfg.BlockMode = false
-				ssoSessionSection, _ := cfg.NewSection("sso-session DEFAULT-SSO")
-				ssoSessionSection.NewKey("sso_start_url", ssoStartURL)
-				ssoSessionSection.NewKey("sso_region", defaultRegion)
-				ssoSessionSection.NewKey("sso_registration_scopes", "sso:account:access")
-				baseProfileSection, _ := cfg.NewSection("profile DEFAULT-SSO")
-				baseProfileSection.NewKey("sso_start_url", ssoStartURL)
-				baseProfileSection.NewKey("sso_region", defaultRegion)
-				baseProfileSection.NewKey("sso_role_name", "itfrun-operator")
-				baseProfileSection.NewKey("region", defaultRegion)
-				baseProfileSection.NewKey("output", "json")
-				// Ensure .aws directory exists
-				awsDir := filepath.Join(homeDir, ".aws")
-				os.MkdirAll(awsDir, 0755)
-				// Write config file
-				writeConfigWithoutEscaping(cfg, configPath)
+				if err := createDefaultSSOConfiguration(configPath, ssoStartURL, base); err != nil {
+					return err
+				}
 				ssoRegion = defaultRegion
-				ssoSessionName = "DEFAULT-SSO"
+				ssoSessionName = base.SessionName
 			} else {
 				iniCfg, iniErr := ini.Load(configPath)
 				if iniErr == nil {
@@ -135,7 +148,7 @@ func generateProfiles() error {
 			return fmt.Errorf("No AWS config file found and --sso-start-url not provided. Please provide --sso-start-url to continue.")
 		}
 		var getInfoErr error
-		ssoStartURL, ssoRegion, ssoSessionName, getInfoErr = getSSOReuiredInfo()
+		ssoStartURL, ssoRegion, ssoSessionName, getInfoErr = getSSOReuiredInfo(base.SessionName)
 		if getInfoErr != nil {
 			return fmt.Errorf("failed to get SSO configuration from config file: %w", getInfoErr)
 		}
@@ -150,7 +163,7 @@ func generateProfiles() error {
 	// Get access token
 	accessToken, err := getSSOAccessToken(ctx, ssoStartURL, ssoRegion)
 	if err != nil {
-		return fmt.Errorf("failed to get SSO access token: %s\n\nTo continue, please login to AWS SSO:\n  aws sso login --profile DEFAULT-SSO\n\nThen run this command again.", err.Error())
+		return fmt.Errorf("failed to get SSO access token: %s\n\nTo continue, please login to AWS SSO:\n  aws sso login --profile %s\n\nThen run this command again.", err.Error(), base.ProfileName)
 	}
 
 	// Create SSO client
@@ -171,15 +184,61 @@ func generateProfiles() error {
 
 	fmt.Printf("Found %d account/role combinations\n", len(accountRoles))
 
+	// Complete the base SSO profile with a role, asking the user to pick one if none is configured
+	if !dryRun && !diffFlag {
+		if err := ensureBaseProfileRole(base, accountRoles); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	// Apply include/exclude filters from the settings file and flags
+	filter, err := loadProfileFilter(settings, profileFilterFlags)
+	if err != nil {
+		return err
+	}
+	if !filter.IsEmpty() {
+		accountRoles = filterAccountRoles(accountRoles, filter)
+		fmt.Printf("%d account/role combinations left after applying filters\n", len(accountRoles))
+		if len(accountRoles) == 0 {
+			return errors.New("filters matched nothing, not touching the config")
+		}
+	}
+
+	// Assign per-account regions from region rules or by probing for EKS clusters
+	regionRules, err := loadRegionRules(settings, regionMapFlag)
+	if err != nil {
+		return err
+	}
+	assignRegions(ctx, accountRoles, regionRules, ssoClient, accessToken)
+
 	// Generate profiles
 	profiles := generateProfilesFromAccountRoles(accountRoles, ssoStartURL, ssoRegion, ssoSessionName)
 
+	if diffFlag {
+		unmanaged, managed, err := loadConfigProfiles()
+		if err != nil {
+			return err
+		}
+		diff := diffProfiles(unmanaged, managed, profiles)
+		fmt.Println()
+		printProfileDiff(os.Stdout, diff, profiles)
+		if diff.HasChanges() {
+			return errProfilesChanged
+		}
+		return nil
+	}
+
 	if dryRun {
 		fmt.Println("\nDry run mode - showing profiles that would be generated:")
-		for profileName, profileConfig := range profiles {
+		var profileNames []string
+		for profileName := range profiles {
+			profileNames = append(profileNames, profileName)
+		}
+		sort.Strings(profileNames)
+		for _, profileName := range profileNames {
 			fmt.Printf("\n[profile %s]\n", profileName)
-			for key, value := range profileConfig {
-				fmt.Printf("%s = %s\n", key, value)
+			for _, key := range sortedKeys(profiles[profileName]) {
+				fmt.Printf("%s = %s\n", key, profiles[profileName][key])
 			}
 		}
 		fmt.Printf("\nTotal profiles that would be generated: %d\n", len(profiles))
@@ -195,7 +254,7 @@ func generateProfiles() error {
 	return nil
 }
 
-func getSSOReuiredInfo() (startURL, region, ssoSessionName string, err error) {
+func getSSOReuiredInfo(preferredSession string) (startURL, region, ssoSessionName string, err error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", "", "", fmt.Errorf("failed to get home directory: %w", err)
@@ -207,6 +266,13 @@ func getSSOReuiredInfo() (startURL, region, ssoSessionName string, err error) {
 		return "", "", "", fmt.Errorf("failed to load AWS config file: %w", err)
 	}
 
+	// Prefer the configured SSO session when it exists
+	if section, sectionErr := cfg.GetSection("sso-session " + preferredSession); sectionErr == nil {
+		if section.HasKey("sso_start_url") && section.HasKey("sso_region") {
+			return section.Key("sso_start_url").String(), section.Key("sso_region").String(), preferredSession, nil
+		}
+	}
+
 	// Look for SSO session configuration first (newer format)
 	for _, section := range cfg.Sections() {
 		if strings.HasPrefix(section.Name(), "sso-session ") {
@@ -247,86 +313,39 @@ func getSSOReuiredInfo() (startURL, region, ssoSessionName string, err error) {
 	return "", "", "", fmt.Errorf("SSO configuration not found in ~/.aws/config. Please ensure you have at least one SSO profile or sso-session configured")
 }
 
-func createDefaultSSOConfiguration() error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	configPath := filepath.Join(homeDir, ".aws", "config")
-
-	// Ensure the .aws directory exists
-	awsDir := filepath.Join(homeDir, ".aws")
-	if err := os.MkdirAll(awsDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .aws directory: %w", err)
-	}
-
-	// Load existing config or create new one
-	var cfg *ini.File
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		cfg = ini.Empty()
-	} else {
-		cfg, err = ini.Load(configPath)
-		if err != nil {
-			return fmt.Errorf("failed to load existing config: %w", err)
-		}
-	}
-
-	// Configure formatting to avoid backticks
-	cfg.BlockMode = false
-
-	// Check if sso-session already exists
-	ssoSessionExists := false
-	for _, section := range cfg.Sections() {
-		if section.Name() == "sso-session DEFAULT-SSO" {
-			ssoSessionExists = true
-			break
-		}
-	}
-
-	// Use the sanitized flag value if provided, else error
-	ssoStartURL := strings.TrimRight(ssoStartURLFlag, "#/\\")
+// createDefaultSSOConfiguration adds the sso-session and the base SSO profile used for
+// "aws sso login" to the config file, unless they already exist
+func createDefaultSSOConfiguration(configPath, ssoStartURL string, base baseSSOConfig) error {
 	if ssoStartURL == "" {
 		return fmt.Errorf("No SSO start URL provided. Please use --sso-start-url flag.")
 	}
 
-	// Create sso-session if it doesn't exist
-	if !ssoSessionExists {
-		ssoSessionSection, err := cfg.NewSection("sso-session DEFAULT-SSO")
-		if err != nil {
-			return fmt.Errorf("failed to create sso-session section: %w", err)
+	return updateUnmanagedConfig(configPath, func(content string) string {
+		sessionSection := "sso-session " + base.SessionName
+		if sectionKeys(content, sessionSection) == nil {
+			content = appendSection(content, sessionSection, map[string]string{
+				"sso_start_url":           ssoStartURL,
+				"sso_region":              defaultRegion,
+				"sso_registration_scopes": "sso:account:access",
+			})
+			fmt.Printf("Created [%s] configuration\n", sessionSection)
 		}
-		ssoSessionSection.NewKey("sso_start_url", ssoStartURL)
-		ssoSessionSection.NewKey("sso_region", defaultRegion)
-		ssoSessionSection.NewKey("sso_registration_scopes", "sso:account:access")
-		fmt.Println("Created [sso-session DEFAULT-SSO] configuration")
-	}
 
-	// Check if base DEFAULT-SSO profile exists
-	baseProfileExists := false
-	for _, section := range cfg.Sections() {
-		if section.Name() == "profile DEFAULT-SSO" {
-			baseProfileExists = true
-			break
-		}
-	}
-
-	// Create base DEFAULT-SSO profile if it doesn't exist
-	if !baseProfileExists {
-		baseProfileSection, err := cfg.NewSection("profile DEFAULT-SSO")
-		if err != nil {
-			return fmt.Errorf("failed to create base profile section: %w", err)
+		profileSection := "profile " + base.ProfileName
+		if sectionKeys(content, profileSection) == nil {
+			profileConfig := map[string]string{
+				"sso_session": base.SessionName,
+				"region":      defaultRegion,
+				"output":      "json",
+			}
+			if base.RoleName != "" {
+				profileConfig["sso_role_name"] = base.RoleName
+			}
+			content = appendSection(content, profileSection, profileConfig)
+			fmt.Printf("Created [%s] base profile\n", profileSection)
 		}
-		baseProfileSection.NewKey("sso_start_url", ssoStartURL)
-		baseProfileSection.NewKey("sso_region", defaultRegion)
-		baseProfileSection.NewKey("sso_role_name", "itfrun-operator")
-		baseProfileSection.NewKey("region", defaultRegion)
-		baseProfileSection.NewKey("output", "json")
-		fmt.Println("Created [profile DEFAULT-SSO] base profile")
-	}
-
-	// Save the configuration
-	return writeConfigWithoutEscaping(cfg, configPath)
+		return content
+	})
 }
 
 // appendToConfig appends text to a config file
@@ -383,59 +402,6 @@ func getAvailableSSOProfiles() []string {
 	return result
 }
 
-func getSSOAccessToken(ctx context.Context, startURL, region string) (string, error) {
-	// Load the cached SSO token
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	// Check for cached tokens in ~/.aws/sso/cache/
-	cacheDir := filepath.Join(homeDir, ".aws", "sso", "cache")
-
-	// List all cache files
-	entries, err := os.ReadDir(cacheDir)
-	if err != nil {
-		return "", fmt.Errorf("failed to read SSO cache directory. Please run 'aws sso login' first: %w", err)
-	}
-
-	for _, entry := range entries {
-		if strings.HasSuffix(entry.Name(), ".json") {
-			cachePath := filepath.Join(cacheDir, entry.Name())
-			token, err := readTokenFromCache(cachePath, startURL)
-			if err == nil && token != "" {
-				return token, nil
-			}
-		}
-	}
-
-	return "", fmt.Errorf("no valid SSO token found. Please run 'aws sso login' first")
-}
-
-func readTokenFromCache(cachePath, startURL string) (string, error) {
-	data, err := os.ReadFile(cachePath)
-	if err != nil {
-		return "", err
-	}
-
-	var token SSOCacheToken
-	if err := json.Unmarshal(data, &token); err != nil {
-		return "", fmt.Errorf("failed to parse cache file: %w", err)
-	}
-
-	// Check if this cache file is for the correct start URL
-	if token.StartURL != startURL {
-		return "", fmt.Errorf("cache file doesn't match start URL")
-	}
-
-	// Check if token is expired
-	if time.Now().After(token.ExpiresAt) {
-		return "", fmt.Errorf("token is expired")
-	}
-
-	return token.AccessToken, nil
-}
-
 func listAccountRoles(ctx context.Context, ssoClient *sso.Client, accessToken string) ([]AccountRole, error) {
 	var accountRoles []AccountRole
 
@@ -491,38 +457,54 @@ func listAccountRoles(ctx context.Context, ssoClient *sso.Client, accessToken st
 	return accountRoles, nil
 }
 
+// profileNameForAccountRole builds the <account>-<role> profile name of an account/role combination
+func profileNameForAccountRole(ar AccountRole) string {
+	// Generate profile name: use account name if available, otherwise account ID
+	accountIdentifier := ar.AccountName
+	if accountIdentifier == "" {
+		accountIdentifier = ar.AccountID
+	}
+
+	// Clean up account identifier for use in profile name
+	accountIdentifier = strings.ReplaceAll(accountIdentifier, " ", "-")
+	accountIdentifier = strings.ReplaceAll(accountIdentifier, ".", "-")
+	accountIdentifier = strings.ToLower(accountIdentifier)
+
+	// Simplify role name
+	roleName := strings.ToLower(ar.RoleName)
+	// If role contains "itfrun-operator", just use "operator"
+	if strings.Contains(roleName, "itfrun-operator") {
+		roleName = "operator"
+	} else if strings.HasPrefix(roleName, "itfrun-") {
+		roleName = strings.TrimPrefix(roleName, "itfrun-")
+	}
+
+	// Generate cleaner profile name: <account>-<role>
+	return fmt.Sprintf("%s-%s", accountIdentifier, roleName)
+}
+
 func generateProfilesFromAccountRoles(accountRoles []AccountRole, ssoStartURL, ssoRegion, ssoSessionName string) map[string]map[string]string {
 	profiles := make(map[string]map[string]string)
 
 	// Use the provided default region or fall back to eu-central-1
-	region := defaultRegion
-	if region == "" {
-		region = "eu-central-1"
+	fallbackRegion := defaultRegion
+	if fallbackRegion == "" {
+		fallbackRegion = "eu-central-1"
 	}
 
-	for _, ar := range accountRoles {
-		// Generate profile name: use account name if available, otherwise account ID
-		accountIdentifier := ar.AccountName
-		if accountIdentifier == "" {
-			accountIdentifier = ar.AccountID
-		}
+	// Resolve profile names, disambiguating names shared by several account/role combinations
+	profileNames, collisions := resolveProfileNames(accountRoles)
+	for _, collision := range collisions {
+		fmt.Printf("Warning: profile name %s is shared by %d account/role combinations, using %s\n",
+			collision.Name, len(collision.Resolved), strings.Join(collision.Resolved, ", "))
+	}
 
-		// Clean up account identifier for use in profile name
-		accountIdentifier = strings.ReplaceAll(accountIdentifier, " ", "-")
-		accountIdentifier = strings.ReplaceAll(accountIdentifier, ".", "-")
-		accountIdentifier = strings.ToLower(accountIdentifier)
-
-		// Simplify role name
-		roleName := strings.ToLower(ar.RoleName)
-		// If role contains "itfrun-operator", just use "operator"
-		if strings.Contains(roleName, "itfrun-operator") {
-			roleName = "operator"
-		} else if strings.HasPrefix(roleName, "itfrun-") {
-			roleName = strings.TrimPrefix(roleName, "itfrun-")
+	for i, ar := range accountRoles {
+		region := ar.Region
+		if region == "" {
+			region = fallbackRegion
 		}
-
-		// Generate cleaner profile name: <account>-<role>
-		profileName := fmt.Sprintf("%s-%s", accountIdentifier, roleName)
+		profileName := profileNames[i]
 
 		// Use sso_session format if available, otherwise fall back to old format
 		profileConfig := map[string]string{
@@ -543,7 +525,7 @@ func generateProfilesFromAccountRoles(accountRoles []AccountRole, ssoStartURL, s
 
 		profiles[profileName] = profileConfig
 
-		if !dryRun {
+		if !dryRun && !diffFlag {
 			fmt.Printf("Generated profile: %s (Account: %s, Role: %s)\n", profileName, ar.AccountName, ar.RoleName)
 		}
 	}
@@ -552,88 +534,21 @@ func generateProfilesFromAccountRoles(accountRoles []AccountRole, ssoStartURL, s
 }
 
 func writeProfilesToConfig(profiles map[string]map[string]string) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	configPath := filepath.Join(homeDir, ".aws", "config")
-
-	// Load existing config
-	cfg, err := ini.Load(configPath)
+	configPath, err := getAwsConfigPath()
 	if err != nil {
-		// If file doesn't exist, create a new one
-		cfg = ini.Empty()
-	}
-
-	// Configure formatting to avoid backticks
-	cfg.BlockMode = false
-
-	// Add or update profiles
-	for profileName, profileConfig := range profiles {
-		sectionName := fmt.Sprintf("profile %s", profileName)
-
-		// Remove existing section if it exists
-		cfg.DeleteSection(sectionName)
-
-		// Create new section
-		section, err := cfg.NewSection(sectionName)
-		if err != nil {
-			return fmt.Errorf("failed to create section %s: %w", sectionName, err)
-		}
-
-		// Add keys
-		for key, value := range profileConfig {
-			section.NewKey(key, value)
-		}
+		return err
 	}
 
-	// Custom save to avoid ini library escaping URLs with #
-	return writeConfigWithoutEscaping(cfg, configPath)
-}
-
-// writeConfigWithoutEscaping manually writes the config file to avoid URL escaping issues
-func writeConfigWithoutEscaping(cfg *ini.File, configPath string) error {
-	// Create a temporary file to write the new content
-	tempFile, err := os.CreateTemp(filepath.Dir(configPath), ".aws-config-temp-")
+	// Profiles are written to the managed block, leaving hand-edited sections untouched
+	moved, err := writeManagedProfiles(configPath, profiles, forceFlag)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return err
 	}
-	defer tempFile.Close()
-	defer os.Remove(tempFile.Name())
-
-	// Write sections manually
-	for _, section := range cfg.Sections() {
-		if section.Name() != "DEFAULT" {
-			if _, err := fmt.Fprintf(tempFile, "[%s]\n", section.Name()); err != nil {
-				return fmt.Errorf("failed to write section header: %w", err)
-			}
-		}
-
-		keys := section.Keys()
-		// Sort keys for consistent output
-		sort.Slice(keys, func(i, j int) bool {
-			return keys[i].Name() < keys[j].Name()
-		})
-
-		for _, key := range keys {
-			value := key.String()
-			// Write key-value pair without escaping
-			if _, err := fmt.Fprintf(tempFile, "%s = %s\n", key.Name(), value); err != nil {
-				return fmt.Errorf("failed to write key-value pair: %w", err)
-			}
-		}
 
-		// Add blank line after each section
-		if _, err := fmt.Fprintf(tempFile, "\n"); err != nil {
-			return fmt.Errorf("failed to write blank line: %w", err)
-		}
+	for _, name := range moved {
+		fmt.Printf("Replaced hand-written profile %s with the generated one\n", name)
 	}
-
-	tempFile.Close()
-
-	// Replace the original file with the temp file
-	return os.Rename(tempFile.Name(), configPath)
+	return nil
 }
 
 func init() {
@@ -644,5 +559,21 @@ func init() {
 
 	generateProfilesCmd.Flags().StringVarP(&defaultRegion, "region", "r", "eu-central-1", "Default AWS region for generated profiles")
 	generateProfilesCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what profiles would be generated without writing to config file")
+	generateProfilesCmd.Flags().BoolVar(&diffFlag, "diff", false, "Show how ~/.aws/config would change without writing it (exits with code 2 when there are changes)")
+	generateProfilesCmd.Flags().BoolVar(&forceFlag, "force", false, "Replace hand-written profiles that have the same name as a generated profile, and repair a managed block without end marker")
 	generateProfilesCmd.Flags().StringVar(&ssoStartURLFlag, "sso-start-url", "", "Override the SSO start URL for generated profiles (optional)")
+	generateProfilesCmd.Flags().StringVar(&baseSSOFlags.ProfileName, "sso-profile", "", `Name of the base SSO profile used for login (default "DEFAULT-SSO")`)
+	generateProfilesCmd.Flags().StringVar(&baseSSOFlags.SessionName, "sso-session", "", `Name of the sso-session to create or use (default "DEFAULT-SSO")`)
+	generateProfilesCmd.Flags().StringVar(&baseSSOFlags.RoleName, "sso-role", "", "Role of the base SSO profile; when not set you are asked to pick one of your roles")
+	generateProfilesCmd.Flags().StringArrayVar(&regionMapFlag, "region-map", nil, "Region for matching accounts as <account-id|name-pattern>=<region> (repeatable)")
+	generateProfilesCmd.Flags().BoolVar(&detectRegionFlag, "detect-region", false, "Detect the region of accounts without a region mapping by probing for EKS clusters")
+	generateProfilesCmd.Flags().StringSliceVar(&probeRegionsFlag, "probe-regions", nil, "Regions to probe with --detect-region (defaults to a list of common regions)")
+	generateProfilesCmd.Flags().StringArrayVar(&profileFilterFlags.IncludeAccounts, "include-account", nil, "Only generate profiles for this account ID (repeatable)")
+	generateProfilesCmd.Flags().StringArrayVar(&profileFilterFlags.ExcludeAccounts, "exclude-account", nil, "Skip this account ID (repeatable)")
+	generateProfilesCmd.Flags().StringArrayVar(&profileFilterFlags.IncludeAccountNames, "include-account-name", nil, "Only generate profiles for account names matching this glob or re:<regex> (repeatable)")
+	generateProfilesCmd.Flags().StringArrayVar(&profileFilterFlags.ExcludeAccountNames, "exclude-account-name", nil, "Skip account names matching this glob or re:<regex> (repeatable)")
+	generateProfilesCmd.Flags().StringArrayVar(&profileFilterFlags.IncludeRoles, "include-role", nil, "Only generate profiles for role names matching this glob or re:<regex> (repeatable)")
+	generateProfilesCmd.Flags().StringArrayVar(&profileFilterFlags.ExcludeRoles, "exclude-role", nil, "Skip role names matching this glob or re:<regex> (repeatable)")
+	generateProfilesCmd.Flags().StringArrayVar(&profileFilterFlags.IncludeEmails, "include-email", nil, "Only generate profiles for account emails matching this glob or re:<regex> (repeatable)")
+	generateProfilesCmd.Flags().StringArrayVar(&profileFilterFlags.ExcludeEmails, "exclude-email", nil, "Skip account emails matching this glob or re:<regex> (repeatable)")
 }
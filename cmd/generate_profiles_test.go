@@ -102,3 +102,76 @@ func TestGenerateProfilesFromAccountRoles(t *testing.T) {
 		t.Errorf("Expected sso_role_name to be 'AdminRole', got '%s'", testProfile["sso_role_name"])
 	}
 }
+
+func TestGenerateProfilesFromAccountRolesUsesAccountRegion(t *testing.T) {
+	originalDryRun := dryRun
+	dryRun = true
+	defer func() { dryRun = originalDryRun }()
+
+	accountRoles := []AccountRole{
+		{AccountID: "123456789012", AccountName: "Team A", RoleName: "Admin", Region: "us-east-1"},
+		{AccountID: "987654321098", AccountName: "Team B", RoleName: "Admin"},
+	}
+
+	profiles := generateProfilesFromAccountRoles(accountRoles, "https://test.awsapps.com/start", "us-east-1", "my-sso")
+
+	if got := profiles["team-a-admin"]["region"]; got != "us-east-1" {
+		t.Errorf("Expected mapped region 'us-east-1', got '%s'", got)
+	}
+	if got := profiles["team-b-admin"]["region"]; got != defaultRegion {
+		t.Errorf("Expected default region '%s', got '%s'", defaultRegion, got)
+	}
+	if got := profiles["team-a-admin"]["sso_session"]; got != "my-sso" {
+		t.Errorf("Expected sso_session 'my-sso', got '%s'", got)
+	}
+}
+
+func TestGenerateProfilesFromAccountRolesDisambiguatesCollisions(t *testing.T) {
+	originalDryRun := dryRun
+	dryRun = true
+	defer func() { dryRun = originalDryRun }()
+
+	accountRoles := []AccountRole{
+		{AccountID: "222222222222", AccountName: "team a", RoleName: "Admin"},
+		{AccountID: "111111111111", AccountName: "Team.A", RoleName: "Admin"},
+		{AccountID: "333333333333", AccountName: "Team B", RoleName: "Admin"},
+	}
+
+	profiles := generateProfilesFromAccountRoles(accountRoles, "https://test.awsapps.com/start", "us-east-1", "")
+
+	if len(profiles) != 3 {
+		t.Fatalf("Expected 3 profiles, got %d: %v", len(profiles), profiles)
+	}
+	for name, account := range map[string]string{
+		"team-a-admin-111111111111": "111111111111",
+		"team-a-admin-222222222222": "222222222222",
+		"team-b-admin":              "333333333333",
+	} {
+		if profiles[name]["sso_account_id"] != account {
+			t.Errorf("Expected profile %s for account %s, got %v", name, account, profiles[name])
+		}
+	}
+
+	// The same role name in different case within one account still gets unique names
+	names, collisions := resolveProfileNames([]AccountRole{
+		{AccountID: "111111111111", AccountName: "a", RoleName: "admin"},
+		{AccountID: "111111111111", AccountName: "a", RoleName: "Admin"},
+	})
+	if names[0] == names[1] || len(collisions) != 1 {
+		t.Errorf("Expected unique names and one collision, got %v %v", names, collisions)
+	}
+}
+
+func TestGenerateProfilesFilterFlagsKeepCommas(t *testing.T) {
+	defer func() { profileFilterFlags = ProfileFilter{} }()
+
+	flags := generateProfilesCmd.Flags()
+	if err := flags.Parse([]string{"--include-account-name", "re:^(dev|prod){1,3}$", "--include-account-name", "team-*"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got := profileFilterFlags.IncludeAccountNames
+	if len(got) != 2 || got[0] != "re:^(dev|prod){1,3}$" || got[1] != "team-*" {
+		t.Errorf("Expected each flag value to be kept whole, got %v", got)
+	}
+}
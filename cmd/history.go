@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// maxHistoryEntries is the number of switches kept in the history file
+const maxHistoryEntries = 200
+
+var (
+	historyLimit int
+	historyList  bool
+)
+
+// historyEntry is a successful switch made with 'use'
+type historyEntry struct {
+	Profile string    `json:"profile"`
+	Cluster string    `json:"cluster"`
+	Context string    `json:"context"`
+	Time    time.Time `json:"time"`
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recent profile and cluster switches and switch back to one of them",
+	Long: `List the most recent switches made with 'use', newest first, and select one to switch to it
+again. Use --list to only print the history.
+
+'asp-eks use -' switches back to the previous profile and cluster directly.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// If export flag is set, redirect informational output to stderr
+		originalWriter := outputWriter
+		if exportFlag {
+			outputWriter = os.Stderr
+		}
+		defer func() { outputWriter = originalWriter }()
+
+		entries, err := recentSwitches(historyLimit)
+		if err != nil {
+			fmt.Fprintln(outputWriter, "Failed to read history:", err)
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(outputWriter, "No switches recorded yet")
+			return
+		}
+
+		for i, entry := range entries {
+			fmt.Fprintf(outputWriter, "[%d] %s  %s / %s\n", i+1, entry.Time.Local().Format("2006-01-02 15:04"), entry.Profile, entry.Cluster)
+		}
+		if historyList {
+			return
+		}
+
+		fmt.Fprint(outputWriter, "Select switch by number: ")
+		reader := bufio.NewReader(os.Stdin)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(outputWriter, "Error reading input:", err)
+			return
+		}
+		choice, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil || choice < 1 || choice > len(entries) {
+			fmt.Fprintln(outputWriter, "Invalid selection")
+			return
+		}
+
+		switchTo(entries[choice-1], exportFlag)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 10, "Number of switches to show")
+	historyCmd.Flags().BoolVar(&historyList, "list", false, "Only list the history, don't prompt for a switch")
+	historyCmd.Flags().BoolVar(&exportFlag, "export", false, "Output shell commands for eval (export AWS_PROFILE)")
+}
+
+func getHistoryPath() string {
+	dir := getSettingsDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "history")
+}
+
+// loadHistory reads the history file, oldest entry first. Lines that can't be parsed are skipped.
+func loadHistory() ([]historyEntry, error) {
+	historyPath := getHistoryPath()
+	if historyPath == "" {
+		return nil, fmt.Errorf("could not determine history path")
+	}
+
+	data, err := os.ReadFile(historyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	var entries []historyEntry
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var entry historyEntry
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &entry) != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// recordHistory appends a switch to the history file, keeping the last maxHistoryEntries
+func recordHistory(entry historyEntry) error {
+	entries, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	entries = append(entries, entry)
+	if len(entries) > maxHistoryEntries {
+		entries = entries[len(entries)-maxHistoryEntries:]
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	historyPath := getHistoryPath()
+	if err := os.MkdirAll(filepath.Dir(historyPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(historyPath), err)
+	}
+	return writeFileAtomically(historyPath, buf.Bytes())
+}
+
+// recentSwitches returns up to limit distinct profile/cluster pairs, most recent first
+func recentSwitches(limit int) ([]historyEntry, error) {
+	entries, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var recent []historyEntry
+	for i := len(entries) - 1; i >= 0 && len(recent) < limit; i-- {
+		key := entries[i].Profile + "\x00" + entries[i].Cluster
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		recent = append(recent, entries[i])
+	}
+	return recent, nil
+}
+
+// previousSwitch returns the switch made before the current one, like 'cd -'
+func previousSwitch() (*historyEntry, error) {
+	recent, err := recentSwitches(2)
+	if err != nil {
+		return nil, err
+	}
+	if len(recent) < 2 {
+		return nil, fmt.Errorf("no previous switch recorded")
+	}
+	return &recent[1], nil
+}
+
+// switchTo logs in to the profile of a history entry and makes its cluster the current context
+func switchTo(entry historyEntry, export bool) {
+	if err := ensureSSO(entry.Profile); err != nil {
+		fmt.Fprintf(outputWriter, "Failed to ensure SSO login: %v\n", err)
+		return
+	}
+	updateKubeconfig(entry.Profile, entry.Cluster, export)
+}
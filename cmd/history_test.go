@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecentSwitches(t *testing.T) {
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+
+	start := time.Date(2025, 5, 12, 9, 0, 0, 0, time.UTC)
+	for i, entry := range []historyEntry{
+		{Profile: "payments-dev", Cluster: "dev-eks"},
+		{Profile: "payments-prod", Cluster: "prod-eks"},
+		{Profile: "payments-dev", Cluster: "dev-eks"},
+		{Profile: "payments-prod", Cluster: "prod-eks"},
+		{Profile: "shared-dev", Cluster: "tools-eks"},
+	} {
+		entry.Context = entry.Cluster
+		entry.Time = start.Add(time.Duration(i) * time.Minute)
+		if err := recordHistory(entry); err != nil {
+			t.Fatalf("Failed to record history: %v", err)
+		}
+	}
+
+	recent, err := recentSwitches(10)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var got []string
+	for _, entry := range recent {
+		got = append(got, entry.Profile)
+	}
+	if strings.Join(got, ",") != "shared-dev,payments-prod,payments-dev" {
+		t.Errorf("Expected distinct switches newest first, got %v", got)
+	}
+
+	previous, err := previousSwitch()
+	if err != nil || previous.Profile != "payments-prod" || previous.Cluster != "prod-eks" {
+		t.Errorf("Expected previous switch payments-prod/prod-eks, got %+v (%v)", previous, err)
+	}
+}
+
+func TestRecordHistoryTruncates(t *testing.T) {
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+
+	for i := 0; i < maxHistoryEntries+5; i++ {
+		if err := recordHistory(historyEntry{Profile: "p", Cluster: "c", Time: time.Unix(int64(i), 0)}); err != nil {
+			t.Fatalf("Failed to record history: %v", err)
+		}
+	}
+	entries, _ := loadHistory()
+	if len(entries) != maxHistoryEntries || entries[0].Time.Unix() != 5 {
+		t.Errorf("Expected the oldest entries to be dropped, got %d entries starting at %d", len(entries), entries[0].Time.Unix())
+	}
+}
+
+func TestUseCommandSwitchBack(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+
+	recordHistory(historyEntry{Profile: "payments-dev", Cluster: "dev-eks", Time: time.Now().Add(-time.Hour)})
+	recordHistory(historyEntry{Profile: "payments-prod", Cluster: "prod-eks", Time: time.Now()})
+
+	originalProvider := clusterProvider
+	clusterProvider = &mockClusterProvider{region: "eu-west-1"}
+	defer func() { clusterProvider = originalProvider }()
+
+	originalCredentialsValidator := credentialsValidator
+	credentialsValidator = func(ctx context.Context, profile string) bool { return true }
+	defer func() { credentialsValidator = originalCredentialsValidator }()
+
+	var output bytes.Buffer
+	outputWriter = &output
+	defer func() { outputWriter = os.Stdout }()
+
+	rootCmd.SetArgs([]string{"use", "-"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.Contains(output.String(), "Switching back to payments-dev / dev-eks") ||
+		!strings.Contains(output.String(), "Successfully updated kubeconfig for cluster: dev-eks") {
+		t.Errorf("Expected to switch back to dev-eks, got: %s", output.String())
+	}
+
+	// Switching back again returns to prod
+	previous, err := previousSwitch()
+	if err != nil || previous.Profile != "payments-prod" {
+		t.Errorf("Expected payments-prod to be the previous switch now, got %+v (%v)", previous, err)
+	}
+}
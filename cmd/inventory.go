@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/eimarfandino/asp-eks/awsutils"
+	"github.com/spf13/cobra"
+)
+
+var inventoryFormat string
+
+// clusterDescriber describes a cluster in a region with a profile - can be mocked in tests
+var clusterDescriber = describeCluster
+
+// accountNamesFetcher returns the SSO account names by account ID - can be mocked in tests
+var accountNamesFetcher = fetchSSOAccountNames
+
+// ClusterDetails is the part of DescribeCluster asp-eks reports on
+type ClusterDetails struct {
+	Name            string
+	Arn             string
+	Version         string
+	PlatformVersion string
+	Status          string
+	EndpointAccess  string
+	CreatedAt       time.Time
+	Tags            map[string]string
+}
+
+// inventoryRow is a cluster in the inventory with the profile it was described with
+type inventoryRow struct {
+	AccountID       string    `json:"accountId"`
+	AccountName     string    `json:"accountName"`
+	Profile         string    `json:"profile"`
+	Region          string    `json:"region"`
+	Cluster         string    `json:"cluster"`
+	Version         string    `json:"version"`
+	PlatformVersion string    `json:"platformVersion"`
+	Status          string    `json:"status"`
+	EndpointAccess  string    `json:"endpointAccess"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+var inventoryColumns = []string{
+	"Account ID", "Account name", "Profile", "Region", "Cluster", "Version",
+	"Platform version", "Status", "Endpoint access", "Created",
+}
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Export an inventory of all EKS clusters as CSV, JSON or Markdown",
+	Long: `Discover the clusters of all profiles (see 'asp-eks discover') and describe each of them once,
+listing account, profile, region, Kubernetes and platform version, status, endpoint access and
+creation time. Account names are looked up with the cached SSO token.
+
+The same --include, --exclude, --region and --concurrency flags as discover select what is scanned.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if inventoryFormat != "csv" && inventoryFormat != "json" && inventoryFormat != "markdown" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Unsupported format %q, use csv, json or markdown\n", inventoryFormat)
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		index, err := discoverClusters(ctx, cmd.ErrOrStderr())
+		if err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+			return
+		}
+		for key, scanErr := range index.Errors {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to scan %s: %s\n", key, scanErr)
+		}
+
+		rows := buildInventory(ctx, cmd.ErrOrStderr(), index)
+
+		out := cmd.OutOrStdout()
+		switch inventoryFormat {
+		case "csv":
+			err = writeInventoryCSV(out, rows)
+		case "json":
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(rows)
+		case "markdown":
+			writeInventoryMarkdown(out, rows)
+		}
+		if err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Failed to write inventory:", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inventoryCmd)
+	inventoryCmd.Flags().StringVarP(&inventoryFormat, "format", "f", "csv", "Output format: csv, json or markdown")
+	inventoryCmd.Flags().StringArrayVar(&discoverInclude, "include", nil, "Only scan profiles matching this pattern (repeatable)")
+	inventoryCmd.Flags().StringArrayVar(&discoverExclude, "exclude", nil, "Skip profiles matching this pattern (repeatable)")
+	inventoryCmd.Flags().StringSliceVar(&discoverRegions, "region", nil, "Regions to scan instead of the profile's own region (repeatable or comma separated)")
+	inventoryCmd.Flags().IntVar(&discoverConcurrency, "concurrency", 8, "Number of clusters described in parallel")
+}
+
+// buildInventory describes every discovered cluster location and keeps one row per cluster ARN,
+// described with the first profile (by name) that can reach it
+func buildInventory(ctx context.Context, progress io.Writer, index *clusterIndex) []inventoryRow {
+	type describeJob struct {
+		cluster  string
+		location clusterLocation
+	}
+	var jobs []describeJob
+	for cluster, locations := range index.Clusters {
+		for _, location := range locations {
+			jobs = append(jobs, describeJob{cluster: cluster, location: location})
+		}
+	}
+
+	concurrency := discoverConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		rows = make(map[string]inventoryRow)
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+	)
+	slots := make(chan struct{}, concurrency)
+
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job describeJob) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			details, err := clusterDescriber(ctx, job.location.Profile, job.location.Region, job.cluster)
+			if err != nil {
+				fmt.Fprintf(progress, "Warning: failed to describe %s with profile %s: %v\n", job.cluster, job.location.Profile, err)
+				return
+			}
+			_, accountID, _, _ := parseClusterArn(details.Arn)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if existing, ok := rows[details.Arn]; ok && existing.Profile < job.location.Profile {
+				return
+			}
+			rows[details.Arn] = inventoryRow{
+				AccountID:       accountID,
+				Profile:         job.location.Profile,
+				Region:          job.location.Region,
+				Cluster:         details.Name,
+				Version:         details.Version,
+				PlatformVersion: details.PlatformVersion,
+				Status:          details.Status,
+				EndpointAccess:  details.EndpointAccess,
+				CreatedAt:       details.CreatedAt,
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	accountNames := lookupAccountNames(ctx, progress, rows)
+
+	var inventory []inventoryRow
+	for _, row := range rows {
+		row.AccountName = accountNames[row.AccountID]
+		inventory = append(inventory, row)
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		if inventory[i].AccountID != inventory[j].AccountID {
+			return inventory[i].AccountID < inventory[j].AccountID
+		}
+		if inventory[i].Region != inventory[j].Region {
+			return inventory[i].Region < inventory[j].Region
+		}
+		return inventory[i].Cluster < inventory[j].Cluster
+	})
+	return inventory
+}
+
+// lookupAccountNames fetches the account names from every SSO login used by the inventory's profiles,
+// following role chains to the profile that holds the SSO settings
+func lookupAccountNames(ctx context.Context, progress io.Writer, rows map[string]inventoryRow) map[string]string {
+	names := make(map[string]string)
+	fetched := make(map[string]bool)
+	for _, row := range rows {
+		chain, err := awsutils.GetProfileChain(row.Profile)
+		if err != nil {
+			continue
+		}
+		startURL, region, _, err := awsutils.GetProfileSSOSettings(chain[len(chain)-1].Name)
+		if err != nil || startURL == "" || fetched[startURL] {
+			continue
+		}
+		fetched[startURL] = true
+
+		accountNames, err := accountNamesFetcher(ctx, startURL, region)
+		if err != nil {
+			fmt.Fprintf(progress, "Warning: failed to look up account names for %s: %v\n", startURL, err)
+			continue
+		}
+		for accountID, name := range accountNames {
+			names[accountID] = name
+		}
+	}
+	return names
+}
+
+func (row inventoryRow) values() []string {
+	created := ""
+	if !row.CreatedAt.IsZero() {
+		created = row.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		row.AccountID, row.AccountName, row.Profile, row.Region, row.Cluster, row.Version,
+		row.PlatformVersion, row.Status, row.EndpointAccess, created,
+	}
+}
+
+func writeInventoryCSV(w io.Writer, rows []inventoryRow) error {
+	writer := csv.NewWriter(w)
+	writer.Write(inventoryColumns)
+	for _, row := range rows {
+		writer.Write(row.values())
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeInventoryMarkdown(w io.Writer, rows []inventoryRow) {
+	fmt.Fprintf(w, "| %s |\n", strings.Join(inventoryColumns, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(inventoryColumns)))
+	for _, row := range rows {
+		values := row.values()
+		for i, value := range values {
+			values[i] = strings.ReplaceAll(value, "|", `\|`)
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(values, " | "))
+	}
+}
+
+// describeCluster calls DescribeCluster with the profile's credentials in the given region
+func describeCluster(ctx context.Context, profile, region, clusterName string) (*ClusterDetails, error) {
+	cfg, err := loadAWSConfig(ctx, profile, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	out, err := eks.NewFromConfig(cfg).DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(clusterName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe EKS cluster: %w", err)
+	}
+
+	cluster := out.Cluster
+	details := &ClusterDetails{
+		Name:            aws.ToString(cluster.Name),
+		Arn:             aws.ToString(cluster.Arn),
+		Version:         aws.ToString(cluster.Version),
+		PlatformVersion: aws.ToString(cluster.PlatformVersion),
+		Status:          string(cluster.Status),
+		CreatedAt:       aws.ToTime(cluster.CreatedAt),
+		Tags:            cluster.Tags,
+	}
+	if vpc := cluster.ResourcesVpcConfig; vpc != nil {
+		details.EndpointAccess = endpointAccessMode(vpc.EndpointPublicAccess, vpc.EndpointPrivateAccess)
+	}
+	return details, nil
+}
+
+// endpointAccessMode names the combination of public and private API server endpoint access
+func endpointAccessMode(public, private bool) string {
+	switch {
+	case public && private:
+		return "public+private"
+	case public:
+		return "public"
+	case private:
+		return "private"
+	}
+	return "none"
+}
+
+// fetchSSOAccountNames lists the accounts visible to the cached SSO token of the start URL
+func fetchSSOAccountNames(ctx context.Context, startURL, region string) (map[string]string, error) {
+	accessToken, err := getSSOAccessToken(ctx, startURL, region)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	ssoClient := sso.New(sso.Options{Region: region})
+	paginator := sso.NewListAccountsPaginator(ssoClient, &sso.ListAccountsInput{AccessToken: aws.String(accessToken)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list accounts: %w", err)
+		}
+		for _, account := range page.AccountList {
+			names[aws.ToString(account.AccountId)] = aws.ToString(account.AccountName)
+		}
+	}
+	return names, nil
+}
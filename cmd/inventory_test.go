@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInventoryCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[sso-session acme]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = eu-central-1
+
+[profile payments-prod-operator]
+sso_session = acme
+region = eu-west-1
+
+[profile payments-prod-readonly]
+sso_session = acme
+region = eu-west-1
+
+[profile shared-dev]
+region = eu-central-1
+`), 0600)
+
+	originalLister, originalDescriber, originalNames, originalValidator := clusterLister, clusterDescriber, accountNamesFetcher, credentialsValidator
+	defer func() {
+		clusterLister, clusterDescriber, accountNamesFetcher, credentialsValidator = originalLister, originalDescriber, originalNames, originalValidator
+	}()
+	credentialsValidator = func(ctx context.Context, profile string) bool { return true }
+	clusterLister = func(ctx context.Context, profile, region string) ([]string, string, error) {
+		if profile == "shared-dev" {
+			return []string{"tools|eks"}, "eu-central-1", nil
+		}
+		return []string{"payments-eks-1"}, "eu-west-1", nil
+	}
+	var described []string
+	clusterDescriber = func(ctx context.Context, profile, region, clusterName string) (*ClusterDetails, error) {
+		described = append(described, profile)
+		account := "123456789012"
+		if profile == "shared-dev" {
+			account = "345678901234"
+		}
+		return &ClusterDetails{
+			Name:            clusterName,
+			Arn:             "arn:aws:eks:" + region + ":" + account + ":cluster/" + clusterName,
+			Version:         "1.29",
+			PlatformVersion: "eks.7",
+			Status:          "ACTIVE",
+			EndpointAccess:  "private",
+			CreatedAt:       time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		}, nil
+	}
+	accountNamesFetcher = func(ctx context.Context, startURL, region string) (map[string]string, error) {
+		return map[string]string{"123456789012": "payments-prod"}, nil
+	}
+
+	var output bytes.Buffer
+	rootCmd.SetOut(&output)
+	rootCmd.SetErr(&bytes.Buffer{})
+	defer func() {
+		inventoryFormat = "csv"
+		rootCmd.SetErr(nil)
+	}()
+
+	rootCmd.SetArgs([]string{"inventory", "--format", "csv"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expectedCSV := `Account ID,Account name,Profile,Region,Cluster,Version,Platform version,Status,Endpoint access,Created
+123456789012,payments-prod,payments-prod-operator,eu-west-1,payments-eks-1,1.29,eks.7,ACTIVE,private,2024-03-01T12:00:00Z
+345678901234,,shared-dev,eu-central-1,tools|eks,1.29,eks.7,ACTIVE,private,2024-03-01T12:00:00Z
+`
+	if output.String() != expectedCSV {
+		t.Errorf("Unexpected CSV output:\n%s", output.String())
+	}
+	if len(described) != 3 {
+		t.Errorf("Expected every location to be described, got %v", described)
+	}
+
+	output.Reset()
+	rootCmd.SetArgs([]string{"inventory", "--format", "markdown"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expectedMarkdown := `| Account ID | Account name | Profile | Region | Cluster | Version | Platform version | Status | Endpoint access | Created |
+| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |
+| 123456789012 | payments-prod | payments-prod-operator | eu-west-1 | payments-eks-1 | 1.29 | eks.7 | ACTIVE | private | 2024-03-01T12:00:00Z |
+| 345678901234 |  | shared-dev | eu-central-1 | tools\|eks | 1.29 | eks.7 | ACTIVE | private | 2024-03-01T12:00:00Z |
+`
+	if output.String() != expectedMarkdown {
+		t.Errorf("Unexpected Markdown output:\n%s", output.String())
+	}
+}
+
+func TestEndpointAccessMode(t *testing.T) {
+	if endpointAccessMode(true, true) != "public+private" || endpointAccessMode(false, true) != "private" || endpointAccessMode(true, false) != "public" {
+		t.Error("Unexpected endpoint access mode")
+	}
+}
+
+func TestLookupAccountNamesFollowsRoleChains(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[sso-session acme]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = eu-central-1
+
+[profile base]
+sso_session = acme
+region = eu-west-1
+
+[profile payments-admin]
+source_profile = base
+role_arn = arn:aws:iam::123456789012:role/admin
+`), 0600)
+
+	originalNames := accountNamesFetcher
+	defer func() { accountNamesFetcher = originalNames }()
+	var fetched []string
+	accountNamesFetcher = func(ctx context.Context, startURL, region string) (map[string]string, error) {
+		fetched = append(fetched, startURL+" "+region)
+		return map[string]string{"123456789012": "payments-prod"}, nil
+	}
+
+	rows := map[string]inventoryRow{"payments": {Profile: "payments-admin"}}
+	names := lookupAccountNames(context.Background(), &bytes.Buffer{}, rows)
+
+	if len(fetched) != 1 || fetched[0] != "https://acme.awsapps.com/start eu-central-1" {
+		t.Errorf("Expected the chain root's SSO login to be used, got %v", fetched)
+	}
+	if names["123456789012"] != "payments-prod" {
+		t.Errorf("Expected the account name to be found, got %v", names)
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// loadKubeConfig loads the merged kubeconfig, honouring the KUBECONFIG environment variable
+func loadKubeConfig() (*api.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	config, err := loadingRules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	return config, nil
+}
+
+// contextProfile returns the AWS profile used by the exec credential plugin of a context
+func contextProfile(config *api.Config, contextName string) string {
+	kubeContext := config.Contexts[contextName]
+	if kubeContext == nil {
+		return ""
+	}
+	authInfo := config.AuthInfos[kubeContext.AuthInfo]
+	if authInfo == nil || authInfo.Exec == nil {
+		return ""
+	}
+	for _, env := range authInfo.Exec.Env {
+		if env.Name == "AWS_PROFILE" {
+			return env.Value
+		}
+	}
+	for i, arg := range authInfo.Exec.Args {
+		if arg == "--profile" && i+1 < len(authInfo.Exec.Args) {
+			return authInfo.Exec.Args[i+1]
+		}
+	}
+	return ""
+}
+
+// contextRoleArn returns the role the token args of a kubeconfig context assume, if any
+func contextRoleArn(config *api.Config, contextName string) string {
+	kubeContext := config.Contexts[contextName]
+	if kubeContext == nil {
+		return ""
+	}
+	authInfo := config.AuthInfos[kubeContext.AuthInfo]
+	if authInfo == nil || authInfo.Exec == nil {
+		return ""
+	}
+	return tokenRoleArn(&ClusterInfo{AuthArgs: authInfo.Exec.Args})
+}
+
+// parseClusterArn splits an EKS cluster ARN (arn:aws:eks:<region>:<account>:cluster/<name>)
+func parseClusterArn(arn string) (region, accountID, name string, ok bool) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[2] != "eks" || !strings.HasPrefix(parts[5], "cluster/") {
+		return "", "", "", false
+	}
+	return parts[3], parts[4], strings.TrimPrefix(parts[5], "cluster/"), true
+}
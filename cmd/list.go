@@ -19,9 +19,18 @@ var listCmd = &cobra.Command{
 			return
 		}
 
+		favouriteProfiles, _ := loadFavouriteTargets()
+		profiles, pinned := pinFavourites(profiles, func(profile string) bool {
+			return favouriteProfiles[profile]
+		})
+
 		fmt.Fprintln(cmd.OutOrStdout(), "Available profiles:")
-		for _, profile := range profiles {
-			fmt.Fprintln(cmd.OutOrStdout(), profile)
+		for i, profile := range profiles {
+			if i < pinned {
+				fmt.Fprintln(cmd.OutOrStdout(), profile, "★")
+			} else {
+				fmt.Fprintln(cmd.OutOrStdout(), profile)
+			}
 		}
 	},
 }
@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/eimarfandino/asp-eks/awsutils"
 )
 
 func TestListCommand(t *testing.T) {
@@ -32,3 +36,38 @@ func TestListCommand(t *testing.T) {
 		t.Errorf("Expected mocked profiles in output, got: %s", got)
 	}
 }
+
+func TestListCommandSkipsNonProfileSections(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[default]
+region = eu-west-1
+
+[profile payments-dev]
+sso_session = x
+
+[sso-session x]
+sso_start_url = https://example.awsapps.com/start
+
+[services y]
+eks =
+  endpoint_url = http://localhost:4566
+`), 0644)
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+
+	originalGetProfiles := getProfiles
+	getProfiles = awsutils.GetAwsProfiles
+	defer func() { getProfiles = originalGetProfiles }()
+
+	var output bytes.Buffer
+	rootCmd.SetOut(&output)
+	rootCmd.SetArgs([]string{"list"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got := output.String(); got != "Available profiles:\ndefault\npayments-dev\n" {
+		t.Errorf("Expected only the default and payments-dev profiles, got: %s", got)
+	}
+}
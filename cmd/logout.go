@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/spf13/cobra"
+	"gopkg.in/ini.v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	logoutProfile      string
+	logoutSSOSession   string
+	logoutAll          bool
+	logoutClearContext bool
+)
+
+// ssoLogout revokes an SSO access token - can be mocked in tests
+var ssoLogout = revokeSSOToken
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Revoke SSO sessions and remove cached AWS credentials",
+	Long: `Revoke the SSO session of a profile or sso-session (or all of them), delete the matching
+tokens in ~/.aws/sso/cache and the cached role credentials in ~/.aws/cli/cache.
+
+Without flags the profile from AWS_PROFILE is logged out.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		out := cmd.OutOrStdout()
+
+		selected := 0
+		for _, set := range []bool{logoutProfile != "", logoutSSOSession != "", logoutAll} {
+			if set {
+				selected++
+			}
+		}
+		if selected > 1 {
+			fmt.Fprintln(out, "Use only one of --profile, --sso-session and --all")
+			return
+		}
+		profile := logoutProfile
+		if selected == 0 {
+			profile = os.Getenv("AWS_PROFILE")
+			if profile == "" {
+				fmt.Fprintln(out, "No profile given and AWS_PROFILE is not set. Use --profile, --sso-session or --all")
+				return
+			}
+		}
+
+		if err := logout(context.Background(), out, profile, logoutSSOSession, logoutAll); err != nil {
+			fmt.Fprintln(out, "Logout failed:", err)
+			return
+		}
+
+		if logoutClearContext {
+			if err := clearCurrentKubeContext(); err != nil {
+				fmt.Fprintln(out, "Failed to clear kubeconfig context:", err)
+				return
+			}
+			fmt.Fprintln(out, "Cleared current kubeconfig context")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+	logoutCmd.Flags().StringVar(&logoutProfile, "profile", "", "Log out the SSO session used by this profile")
+	logoutCmd.Flags().StringVar(&logoutSSOSession, "sso-session", "", "Log out this sso-session")
+	logoutCmd.Flags().BoolVar(&logoutAll, "all", false, "Log out all SSO sessions and remove all cached credentials")
+	logoutCmd.Flags().BoolVar(&logoutClearContext, "clear-context", false, "Also unset the current kubeconfig context")
+}
+
+// ssoLogin identifies an SSO login: its start URL and, for sso-session logins, the session name
+type ssoLogin struct {
+	StartURL    string
+	SessionName string
+}
+
+// logout revokes and removes the cached credentials of a profile, an sso-session or everything
+func logout(ctx context.Context, out io.Writer, profile, ssoSession string, all bool) error {
+	awsConfigPath, err := getAwsConfigPath()
+	if err != nil {
+		return err
+	}
+	awsConfig, err := ini.Load(awsConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config file: %w", err)
+	}
+
+	var login ssoLogin
+	switch {
+	case profile != "":
+		// The default profile may be written as [default] instead of [profile default]
+		sectionName := "profile " + profile
+		if profile == "default" && !awsConfig.HasSection(sectionName) {
+			sectionName = "default"
+		}
+		section, err := awsConfig.GetSection(sectionName)
+		if err != nil {
+			return fmt.Errorf("profile %s not found in AWS config file", profile)
+		}
+		login.SessionName = section.Key("sso_session").String()
+		login.StartURL = section.Key("sso_start_url").String()
+	case ssoSession != "":
+		login.SessionName = ssoSession
+	}
+	if login.SessionName != "" {
+		section, err := awsConfig.GetSection("sso-session " + login.SessionName)
+		if err != nil {
+			return fmt.Errorf("sso-session %s not found in AWS config file", login.SessionName)
+		}
+		login.StartURL = section.Key("sso_start_url").String()
+	}
+	if !all && login.StartURL == "" {
+		return fmt.Errorf("profile %s doesn't use SSO", profile)
+	}
+
+	revoked, err := removeSSOTokens(ctx, out, login, all)
+	if err != nil {
+		return err
+	}
+	removedCreds, err := removeCLICredentials(awsConfig, login, all)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Logged out: revoked %d SSO session(s), removed %d cached role credential(s)\n", revoked, removedCreds)
+	return nil
+}
+
+// removeSSOTokens revokes and deletes the cached SSO tokens of the login, or all of them
+func removeSSOTokens(ctx context.Context, out io.Writer, login ssoLogin, all bool) (int, error) {
+	cacheDir, err := getSSOCacheDir()
+	if err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(cacheDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read SSO cache directory: %w", err)
+	}
+
+	revoked := 0
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		cachePath := filepath.Join(cacheDir, entry.Name())
+		token, err := loadSSOCacheToken(cachePath)
+		isToken := err == nil && token.AccessToken != ""
+		if !all && (!isToken || token.StartURL != login.StartURL) {
+			continue
+		}
+
+		if isToken && time.Now().Before(token.ExpiresAt) {
+			if err := ssoLogout(ctx, token.Region, token.AccessToken); err != nil {
+				fmt.Fprintf(out, "Warning: failed to revoke SSO session for %s: %v\n", token.StartURL, err)
+			} else {
+				revoked++
+			}
+		}
+		if err := os.Remove(cachePath); err != nil {
+			return revoked, fmt.Errorf("failed to remove %s: %w", cachePath, err)
+		}
+	}
+	return revoked, nil
+}
+
+// removeCLICredentials deletes the role credentials the AWS CLI cached for profiles of the
+// login, or all cached credentials
+func removeCLICredentials(awsConfig *ini.File, login ssoLogin, all bool) (int, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	cacheDir := filepath.Join(homeDir, ".aws", "cli", "cache")
+
+	var paths []string
+	if all {
+		entries, err := os.ReadDir(cacheDir)
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read CLI cache directory: %w", err)
+		}
+		for _, entry := range entries {
+			if strings.HasSuffix(entry.Name(), ".json") {
+				paths = append(paths, filepath.Join(cacheDir, entry.Name()))
+			}
+		}
+	} else {
+		for _, section := range awsConfig.Sections() {
+			accountID := section.Key("sso_account_id").String()
+			roleName := section.Key("sso_role_name").String()
+			if accountID == "" || roleName == "" {
+				continue
+			}
+			sameLogin := (login.SessionName != "" && section.Key("sso_session").String() == login.SessionName) ||
+				section.Key("sso_start_url").String() == login.StartURL
+			if !sameLogin {
+				continue
+			}
+			for _, key := range cliCacheKeys(accountID, roleName, login) {
+				paths = append(paths, filepath.Join(cacheDir, key+".json"))
+			}
+		}
+	}
+
+	removed := 0
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err == nil {
+			removed++
+		} else if !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return removed, nil
+}
+
+// cliCacheKeys returns the file names (without extension) the AWS CLI uses to cache SSO role
+// credentials: the SHA1 of the sorted, compact JSON of account, role and session or start URL
+func cliCacheKeys(accountID, roleName string, login ssoLogin) []string {
+	var candidates []map[string]string
+	if login.SessionName != "" {
+		candidates = append(candidates, map[string]string{"accountId": accountID, "roleName": roleName, "sessionName": login.SessionName})
+	}
+	if login.StartURL != "" {
+		candidates = append(candidates, map[string]string{"accountId": accountID, "roleName": roleName, "startUrl": login.StartURL})
+	}
+
+	var keys []string
+	for _, args := range candidates {
+		// encoding/json sorts map keys and writes compact JSON, matching the CLI's
+		// json.dumps(args, sort_keys=True, separators=(',', ':'))
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(args)
+		sum := sha1.Sum(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+		keys = append(keys, hex.EncodeToString(sum[:]))
+	}
+	return keys
+}
+
+// revokeSSOToken calls the SSO Logout API for the access token
+func revokeSSOToken(ctx context.Context, region, accessToken string) error {
+	ssoClient := sso.New(sso.Options{Region: region})
+	_, err := ssoClient.Logout(ctx, &sso.LogoutInput{AccessToken: aws.String(accessToken)})
+	return err
+}
+
+func clearCurrentKubeContext() error {
+	configPath := getDefaultKubeConfigPath()
+	if configPath == "" {
+		return fmt.Errorf("could not determine kubeconfig path")
+	}
+
+	loadingRules := clientcmd.ClientConfigLoadingRules{
+		Precedence: []string{configPath},
+	}
+	kubeConfig, err := loadingRules.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	kubeConfig.CurrentContext = ""
+
+	configAccess := clientcmd.NewDefaultPathOptions()
+	configAccess.GlobalFile = configPath
+	return clientcmd.ModifyConfig(configAccess, *kubeConfig, true)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogoutCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	os.MkdirAll(filepath.Join(home, ".aws", "cli", "cache"), 0700)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[sso-session acme]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = eu-central-1
+
+[sso-session other]
+sso_start_url = https://other.awsapps.com/start
+sso_region = eu-central-1
+
+[profile payments-prod]
+sso_session = acme
+sso_account_id = 123456789012
+sso_role_name = operator
+
+[profile other-dev]
+sso_session = other
+sso_account_id = 210987654321
+sso_role_name = operator
+`), 0600)
+
+	acmeToken := writeSSOCacheFile(t, home, "acme.json", map[string]interface{}{
+		"startUrl": "https://acme.awsapps.com/start", "region": "eu-central-1",
+		"accessToken": "acme-token", "expiresAt": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+	otherToken := writeSSOCacheFile(t, home, "other.json", map[string]interface{}{
+		"startUrl": "https://other.awsapps.com/start", "region": "eu-central-1",
+		"accessToken": "other-token", "expiresAt": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+
+	login := ssoLogin{StartURL: "https://acme.awsapps.com/start", SessionName: "acme"}
+	roleCreds := filepath.Join(home, ".aws", "cli", "cache", cliCacheKeys("123456789012", "operator", login)[0]+".json")
+	otherCreds := filepath.Join(home, ".aws", "cli", "cache", "unrelated.json")
+	os.WriteFile(roleCreds, []byte(`{"ProviderType": "sso"}`), 0600)
+	os.WriteFile(otherCreds, []byte(`{"ProviderType": "sso"}`), 0600)
+
+	originalLogout := ssoLogout
+	defer func() { ssoLogout = originalLogout }()
+	var revokedTokens []string
+	ssoLogout = func(ctx context.Context, region, accessToken string) error {
+		revokedTokens = append(revokedTokens, accessToken)
+		return nil
+	}
+
+	var output bytes.Buffer
+	rootCmd.SetOut(&output)
+	rootCmd.SetArgs([]string{"logout", "--profile", "payments-prod"})
+	defer func() { logoutProfile = "" }()
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(revokedTokens) != 1 || revokedTokens[0] != "acme-token" {
+		t.Errorf("Expected only the acme token to be revoked, got %v", revokedTokens)
+	}
+	if _, err := os.Stat(acmeToken); !os.IsNotExist(err) {
+		t.Error("Expected the acme SSO token to be removed")
+	}
+	if _, err := os.Stat(roleCreds); !os.IsNotExist(err) {
+		t.Error("Expected the cached role credentials of payments-prod to be removed")
+	}
+	if _, err := os.Stat(otherToken); err != nil {
+		t.Error("Expected the token of the other sso-session to be kept")
+	}
+	if _, err := os.Stat(otherCreds); err != nil {
+		t.Error("Expected unrelated cached credentials to be kept")
+	}
+	if !strings.Contains(output.String(), "revoked 1 SSO session(s), removed 1 cached role credential(s)") {
+		t.Errorf("Unexpected output: %s", output.String())
+	}
+}
+
+func TestCLICacheKeys(t *testing.T) {
+	// Key of {"accountId":"123456789012","roleName":"operator","sessionName":"acme"}
+	keys := cliCacheKeys("123456789012", "operator", ssoLogin{SessionName: "acme"})
+	if len(keys) != 1 {
+		t.Fatalf("Expected one cache key, got %v", keys)
+	}
+	if keys[0] != "d970ca697de2314365151871ee2f743e50dcd15f" {
+		t.Errorf("Unexpected cache key %s", keys[0])
+	}
+}
+
+func TestLogoutDefaultProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	os.MkdirAll(filepath.Join(home, ".aws"), 0700)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[default]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = eu-central-1
+sso_account_id = 123456789012
+sso_role_name = operator
+`), 0600)
+	acmeToken := writeSSOCacheFile(t, home, "acme.json", map[string]interface{}{
+		"startUrl": "https://acme.awsapps.com/start", "region": "eu-central-1",
+		"accessToken": "acme-token", "expiresAt": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+
+	originalLogout := ssoLogout
+	defer func() { ssoLogout = originalLogout }()
+	ssoLogout = func(ctx context.Context, region, accessToken string) error { return nil }
+
+	var output bytes.Buffer
+	if err := logout(context.Background(), &output, "default", "", false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(acmeToken); !os.IsNotExist(err) {
+		t.Error("Expected the SSO token of the default profile to be removed")
+	}
+	if !strings.Contains(output.String(), "revoked 1 SSO session(s)") {
+		t.Errorf("Unexpected output: %s", output.String())
+	}
+}
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// Generated profiles live in a delimited block of ~/.aws/config so regeneration never touches
+// hand-edited sections. A separate include file is not an option: the AWS CLI, which is used for
+// "sso login" and "eks get-token", only reads a single config file.
+const (
+	managedBlockBegin = "# >>> asp-eks managed profiles >>>"
+	managedBlockEnd   = "# <<< asp-eks managed profiles <<<"
+	managedBlockNote  = "# Generated by 'asp-eks generate-profiles'. Changes inside this block are overwritten."
+)
+
+var sectionHeaderRegexp = regexp.MustCompile(`^\s*\[([^\]]+)\]\s*$`)
+
+func getAwsConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".aws", "config"), nil
+}
+
+// errUnterminatedManagedBlock is returned when the config file has a begin marker without an end
+// marker. Where the managed block ends can't be told apart from hand-edited sections after it, so
+// nothing is written until the end marker is restored or the block is repaired with --force.
+var errUnterminatedManagedBlock = fmt.Errorf("~/.aws/config has %q without %q, add the end marker after the generated profiles or run 'asp-eks generate-profiles --force' to keep everything after the begin marker as hand-written",
+	managedBlockBegin, managedBlockEnd)
+
+// splitManagedBlock separates the hand-edited content of the config file from the body of the
+// managed block. The markers themselves are not part of either result.
+func splitManagedBlock(content string) (unmanaged, managed string, err error) {
+	begin := strings.Index(content, managedBlockBegin)
+	if begin == -1 {
+		return content, "", nil
+	}
+
+	rest := content[begin+len(managedBlockBegin):]
+	end := strings.Index(rest, managedBlockEnd)
+	if end == -1 {
+		return "", "", errUnterminatedManagedBlock
+	}
+
+	after := strings.TrimPrefix(rest[end+len(managedBlockEnd):], "\n")
+	return content[:begin] + after, rest[:end], nil
+}
+
+// repairManagedBlock drops the begin marker of an unterminated managed block, so everything after
+// it is kept as hand-edited content
+func repairManagedBlock(content string) string {
+	begin := strings.Index(content, managedBlockBegin)
+	if begin == -1 {
+		return content
+	}
+	rest := strings.TrimPrefix(content[begin+len(managedBlockBegin):], "\n")
+	rest = strings.TrimPrefix(rest, managedBlockNote+"\n")
+	return content[:begin] + rest
+}
+
+// removeSections drops the named sections from ini formatted content while keeping every other
+// line, including comments, untouched. It returns the new content and the removed section names.
+func removeSections(content string, names map[string]bool) (string, []string) {
+	var kept []string
+	var removed []string
+	skipping := false
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if match := sectionHeaderRegexp.FindStringSubmatch(line); match != nil {
+			name := strings.TrimSpace(match[1])
+			skipping = names[name]
+			if skipping {
+				removed = append(removed, name)
+			}
+		}
+		if !skipping {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, ""), removed
+}
+
+// parseProfiles returns the profile sections of ini formatted content
+func parseProfiles(content string) (map[string]map[string]string, error) {
+	profiles := make(map[string]map[string]string)
+	if strings.TrimSpace(content) == "" {
+		return profiles, nil
+	}
+
+	cfg, err := ini.Load([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	for _, section := range cfg.Sections() {
+		if name, ok := strings.CutPrefix(section.Name(), "profile "); ok {
+			profiles[name] = section.KeysHash()
+		}
+	}
+	return profiles, nil
+}
+
+// loadConfigProfiles reads ~/.aws/config and returns the hand-edited and the managed profiles
+func loadConfigProfiles() (unmanaged, managed map[string]map[string]string, err error) {
+	configPath, err := getAwsConfigPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read AWS config file: %w", err)
+	}
+
+	unmanagedContent, managedContent, err := splitManagedBlock(string(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	if unmanaged, err = parseProfiles(unmanagedContent); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse AWS config file: %w", err)
+	}
+	if managed, err = parseProfiles(managedContent); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse asp-eks managed profiles: %w", err)
+	}
+	return unmanaged, managed, nil
+}
+
+// renderManagedBlock renders the profiles sorted by name with sorted keys, without escaping values
+func renderManagedBlock(profiles map[string]map[string]string) string {
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(managedBlockBegin + "\n")
+	b.WriteString(managedBlockNote + "\n\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "[profile %s]\n", name)
+		for _, key := range sortedKeys(profiles[name]) {
+			fmt.Fprintf(&b, "%s = %s\n", key, profiles[name][key])
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(managedBlockEnd + "\n")
+	return b.String()
+}
+
+// writeManagedProfiles replaces the managed block of configPath with the given profiles.
+// Hand-written sections with the same name as a generated profile are only replaced when force
+// is set, in which case they are moved into the block. An unterminated managed block is an error,
+// unless force is set, in which case its begin marker is dropped first.
+func writeManagedProfiles(configPath string, profiles map[string]map[string]string, force bool) ([]string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read AWS config file: %w", err)
+	}
+
+	unmanaged, _, err := splitManagedBlock(string(data))
+	if errors.Is(err, errUnterminatedManagedBlock) && force {
+		unmanaged, _, err = splitManagedBlock(repairManagedBlock(string(data)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for name := range profiles {
+		names["profile "+name] = true
+	}
+	unmanaged, moved := removeSections(unmanaged, names)
+	if len(moved) > 0 && !force {
+		sort.Strings(moved)
+		return nil, fmt.Errorf("refusing to overwrite hand-written profiles: %s (use --force to replace them)",
+			strings.Join(trimProfilePrefix(moved), ", "))
+	}
+
+	content := strings.TrimRight(unmanaged, "\n")
+	if content != "" {
+		content += "\n\n"
+	}
+	content += renderManagedBlock(profiles)
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create .aws directory: %w", err)
+	}
+	if err := writeFileAtomically(configPath, []byte(content)); err != nil {
+		return nil, err
+	}
+
+	return trimProfilePrefix(moved), nil
+}
+
+func trimProfilePrefix(sectionNames []string) []string {
+	names := make([]string, len(sectionNames))
+	for i, sectionName := range sectionNames {
+		names[i] = strings.TrimPrefix(sectionName, "profile ")
+	}
+	return names
+}
+
+// writeFileAtomically writes data to a temp file next to path and renames it into place
+func writeFileAtomically(path string, data []byte) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), ".asp-eks-temp-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	return os.Rename(tempFile.Name(), path)
+}
+
+// updateUnmanagedConfig applies update to the hand-edited part of configPath, keeping the
+// managed block as it is
+func updateUnmanagedConfig(configPath string, update func(content string) string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read AWS config file: %w", err)
+	}
+
+	unmanaged, managed, err := splitManagedBlock(string(data))
+	if err != nil {
+		return err
+	}
+	content := strings.TrimRight(update(unmanaged), "\n") + "\n"
+	if strings.Contains(string(data), managedBlockBegin) {
+		content += "\n" + managedBlockBegin + managed + managedBlockEnd + "\n"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create .aws directory: %w", err)
+	}
+	return writeFileAtomically(configPath, []byte(strings.TrimLeft(content, "\n")))
+}
+
+// sectionKeys returns the keys of the named section in ini formatted content, or nil if the
+// section doesn't exist
+func sectionKeys(content, sectionName string) map[string]string {
+	cfg, err := ini.Load([]byte(content))
+	if err != nil {
+		return nil
+	}
+	section, err := cfg.GetSection(sectionName)
+	if err != nil {
+		return nil
+	}
+	return section.KeysHash()
+}
+
+// appendSection adds a new section with sorted keys to the end of ini formatted content
+func appendSection(content, sectionName string, keys map[string]string) string {
+	var b strings.Builder
+	if trimmed := strings.TrimRight(content, "\n"); trimmed != "" {
+		b.WriteString(trimmed + "\n\n")
+	}
+	fmt.Fprintf(&b, "[%s]\n", sectionName)
+	for _, key := range sortedKeys(keys) {
+		fmt.Fprintf(&b, "%s = %s\n", key, keys[key])
+	}
+	return b.String()
+}
+
+// setSectionKeys sets keys of an existing section in ini formatted content, replacing the lines of
+// keys that already exist and adding the others right after the section header
+func setSectionKeys(content, sectionName string, keys map[string]string) string {
+	lines := strings.SplitAfter(content, "\n")
+	var result []string
+	pending := make(map[string]string)
+	for key, value := range keys {
+		pending[key] = value
+	}
+
+	inSection := false
+	for _, line := range lines {
+		if match := sectionHeaderRegexp.FindStringSubmatch(line); match != nil {
+			inSection = strings.TrimSpace(match[1]) == sectionName
+			result = append(result, line)
+			if inSection {
+				if !strings.HasSuffix(line, "\n") {
+					result[len(result)-1] += "\n"
+				}
+				for _, key := range sortedKeys(keys) {
+					result = append(result, fmt.Sprintf("%s = %s\n", key, keys[key]))
+				}
+			}
+			continue
+		}
+		if inSection {
+			if key, _, ok := strings.Cut(line, "="); ok {
+				if _, replaced := pending[strings.TrimSpace(key)]; replaced {
+					continue
+				}
+			}
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, "")
+}
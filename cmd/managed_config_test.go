@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteManagedProfiles(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	original := `# my personal profiles
+[profile personal]
+region = eu-west-1
+
+[profile team-a-operator]
+region = us-east-1
+
+[sso-session my-sso]
+sso_start_url = https://test.awsapps.com/start#/
+sso_region = eu-central-1
+`
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	profiles := map[string]map[string]string{
+		"team-a-operator": {"sso_session": "my-sso", "sso_account_id": "111111111111", "sso_role_name": "operator"},
+		"team-b-operator": {"sso_session": "my-sso", "sso_account_id": "222222222222", "sso_role_name": "operator"},
+	}
+
+	if _, err := writeManagedProfiles(configPath, profiles, false); err == nil || !strings.Contains(err.Error(), "team-a-operator") {
+		t.Fatalf("Expected hand-written team-a-operator to be protected without force, got %v", err)
+	}
+
+	moved, err := writeManagedProfiles(configPath, profiles, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(moved) != 1 || moved[0] != "team-a-operator" {
+		t.Errorf("Expected team-a-operator to be moved into the managed block, got %v", moved)
+	}
+
+	// Regenerating with fewer profiles replaces the block and keeps hand-edited content
+	delete(profiles, "team-b-operator")
+	if _, err := writeManagedProfiles(configPath, profiles, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, _ := os.ReadFile(configPath)
+	content := string(data)
+	for _, want := range []string{"# my personal profiles", "[profile personal]", "sso_start_url = https://test.awsapps.com/start#/", managedBlockBegin, managedBlockEnd} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected config to contain %q, got:\n%s", want, content)
+		}
+	}
+	if strings.Count(content, "[profile team-a-operator]") != 1 {
+		t.Errorf("Expected exactly one team-a-operator section, got:\n%s", content)
+	}
+	if strings.Contains(content, "team-b-operator") || strings.Count(content, managedBlockBegin) != 1 {
+		t.Errorf("Expected the managed block to be replaced, got:\n%s", content)
+	}
+
+	unmanaged, managed, _ := splitManagedBlock(content)
+	if strings.Contains(unmanaged, "team-a-operator") || !strings.Contains(managed, "team-a-operator") {
+		t.Errorf("Expected team-a-operator to live only in the managed block")
+	}
+}
+
+func TestWriteManagedProfilesUnterminatedBlock(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	original := `[profile personal]
+region = eu-west-1
+
+` + managedBlockBegin + `
+` + managedBlockNote + `
+
+[profile team-a-operator]
+sso_account_id = 111111111111
+
+[profile hand-written-after-block]
+region = us-east-1
+`
+	os.WriteFile(configPath, []byte(original), 0600)
+	profiles := map[string]map[string]string{
+		"team-a-operator": {"sso_session": "my-sso", "sso_account_id": "111111111111", "sso_role_name": "operator"},
+	}
+
+	if _, err := writeManagedProfiles(configPath, profiles, false); !errors.Is(err, errUnterminatedManagedBlock) {
+		t.Fatalf("Expected an unterminated block error, got %v", err)
+	}
+	if err := updateUnmanagedConfig(configPath, func(content string) string { return content }); !errors.Is(err, errUnterminatedManagedBlock) {
+		t.Fatalf("Expected an unterminated block error, got %v", err)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != original {
+		t.Fatalf("Expected the config file to stay untouched, got:\n%s", data)
+	}
+
+	// --force keeps everything after the stray marker as hand-written and writes a new block
+	moved, err := writeManagedProfiles(configPath, profiles, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(moved) != 1 || moved[0] != "team-a-operator" {
+		t.Errorf("Expected team-a-operator to be moved into the managed block, got %v", moved)
+	}
+	data, _ := os.ReadFile(configPath)
+	unmanaged, managed, err := splitManagedBlock(string(data))
+	if err != nil {
+		t.Fatalf("Expected a terminated block after the repair, got %v", err)
+	}
+	if !strings.Contains(unmanaged, "[profile personal]") || !strings.Contains(unmanaged, "[profile hand-written-after-block]") {
+		t.Errorf("Expected hand-written profiles to be kept, got:\n%s", data)
+	}
+	if !strings.Contains(managed, "[profile team-a-operator]") || strings.Count(string(data), managedBlockBegin) != 1 {
+		t.Errorf("Expected team-a-operator in a single managed block, got:\n%s", data)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+var diffFlag bool
+
+// errProfilesChanged is returned by generate-profiles --diff when the config file would change
+var errProfilesChanged = errors.New("generated profiles differ from ~/.aws/config")
+
+// profileChange describes a single key that differs between the existing and generated profile
+type profileChange struct {
+	Key string
+	Old string
+	New string
+}
+
+// profileDiff describes how ~/.aws/config would change when writing the generated profiles
+type profileDiff struct {
+	Added   []string
+	Adopted []string
+	Changed map[string][]profileChange
+	Removed []string
+}
+
+func (d profileDiff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Adopted) > 0 || len(d.Changed) > 0 || len(d.Removed) > 0
+}
+
+// diffProfiles compares the generated profiles against the profiles in ~/.aws/config.
+// Managed profiles that are not generated anymore are reported as removed, and generated
+// profiles clashing with a hand-written profile outside the managed block as adopted.
+func diffProfiles(unmanaged, managed, generated map[string]map[string]string) profileDiff {
+	diff := profileDiff{Changed: make(map[string][]profileChange)}
+
+	for name, profileConfig := range generated {
+		if _, handWritten := unmanaged[name]; handWritten {
+			diff.Adopted = append(diff.Adopted, name)
+		}
+		current, exists := managed[name]
+		if !exists {
+			current, exists = unmanaged[name]
+		}
+		if !exists {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+
+		var changes []profileChange
+		for _, key := range sortedKeys(profileConfig, current) {
+			if current[key] != profileConfig[key] {
+				changes = append(changes, profileChange{Key: key, Old: current[key], New: profileConfig[key]})
+			}
+		}
+		if len(changes) > 0 {
+			diff.Changed[name] = changes
+		}
+	}
+
+	for name := range managed {
+		if _, stillGenerated := generated[name]; !stillGenerated {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Adopted)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// printProfileDiff writes the diff in a unified-diff like format
+func printProfileDiff(w io.Writer, diff profileDiff, generated map[string]map[string]string) {
+	fmt.Fprintln(w, "--- ~/.aws/config")
+	fmt.Fprintln(w, "+++ generated profiles")
+
+	for _, name := range diff.Added {
+		fmt.Fprintf(w, "\n+ [profile %s]\n", name)
+		for _, key := range sortedKeys(generated[name]) {
+			fmt.Fprintf(w, "+ %s = %s\n", key, generated[name][key])
+		}
+	}
+
+	var changed []string
+	for name := range diff.Changed {
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+	for _, name := range changed {
+		fmt.Fprintf(w, "\n~ [profile %s]\n", name)
+		for _, change := range diff.Changed[name] {
+			if change.Old != "" {
+				fmt.Fprintf(w, "- %s = %s\n", change.Key, change.Old)
+			}
+			if change.New != "" {
+				fmt.Fprintf(w, "+ %s = %s\n", change.Key, change.New)
+			}
+		}
+	}
+
+	for _, name := range diff.Adopted {
+		fmt.Fprintf(w, "\n! [profile %s] (hand-written profile, replaced only with --force)\n", name)
+	}
+
+	for _, name := range diff.Removed {
+		fmt.Fprintf(w, "\n- [profile %s]\n", name)
+	}
+
+	fmt.Fprintf(w, "\n%d added, %d changed, %d removed, %d conflicting with hand-written profiles\n",
+		len(diff.Added), len(diff.Changed), len(diff.Removed), len(diff.Adopted))
+}
+
+// sortedKeys returns the union of the keys of the given maps in sorted order
+func sortedKeys(maps ...map[string]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDiffProfiles(t *testing.T) {
+	unmanaged := map[string]map[string]string{
+		"team-b-operator": {"sso_session": "my-sso", "sso_account_id": "222222222222", "sso_role_name": "operator", "region": "eu-west-1", "output": "json"},
+		"personal":        {"aws_access_key_id": "AKIA", "region": "eu-west-1"},
+	}
+	managed := map[string]map[string]string{
+		"team-a-operator": {"sso_session": "my-sso", "sso_account_id": "111111111111", "sso_role_name": "operator", "region": "eu-central-1", "output": "json"},
+		"old-operator":    {"sso_session": "my-sso", "sso_account_id": "333333333333", "sso_role_name": "operator", "region": "eu-west-1"},
+	}
+	generated := map[string]map[string]string{
+		"team-a-operator": {"sso_session": "my-sso", "sso_account_id": "111111111111", "sso_role_name": "operator", "region": "eu-west-1", "output": "json"},
+		"team-b-operator": {"sso_session": "my-sso", "sso_account_id": "222222222222", "sso_role_name": "operator", "region": "eu-west-1", "output": "json"},
+		"team-c-operator": {"sso_session": "my-sso", "sso_account_id": "555555555555", "sso_role_name": "operator", "region": "eu-west-1", "output": "json"},
+	}
+
+	diff := diffProfiles(unmanaged, managed, generated)
+
+	if !diff.HasChanges() {
+		t.Fatal("Expected changes")
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "team-c-operator" {
+		t.Errorf("Expected team-c-operator to be added, got %v", diff.Added)
+	}
+	if len(diff.Adopted) != 1 || diff.Adopted[0] != "team-b-operator" {
+		t.Errorf("Expected team-b-operator to be moved into the managed block, got %v", diff.Adopted)
+	}
+	if len(diff.Changed) != 1 || len(diff.Changed["team-a-operator"]) != 1 || diff.Changed["team-a-operator"][0].Key != "region" {
+		t.Errorf("Expected only the region of team-a-operator to change, got %v", diff.Changed)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "old-operator" {
+		t.Errorf("Expected old-operator to be removed, got %v", diff.Removed)
+	}
+
+	var output bytes.Buffer
+	printProfileDiff(&output, diff, generated)
+	got := output.String()
+	for _, want := range []string{"+ [profile team-c-operator]", "- region = eu-central-1", "+ region = eu-west-1", "- [profile old-operator]", "! [profile team-b-operator]", "1 added, 1 changed, 1 removed, 1 conflicting"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected diff output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestDiffProfilesWithoutChanges(t *testing.T) {
+	profiles := map[string]map[string]string{
+		"team-a-operator": {"sso_session": "my-sso", "sso_account_id": "111111111111", "sso_role_name": "operator"},
+	}
+
+	diff := diffProfiles(nil, profiles, profiles)
+	if diff.HasChanges() {
+		t.Errorf("Expected no changes, got %+v", diff)
+	}
+}
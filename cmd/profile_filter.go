@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gopkg.in/ini.v1"
+)
+
+// ProfileFilter selects which account/role combinations get a generated profile.
+// Each include list, when non-empty, must match; any matching exclude pattern drops the entry.
+// Patterns are case-insensitive globs, or regular expressions when prefixed with "re:".
+type ProfileFilter struct {
+	IncludeAccounts     []string
+	ExcludeAccounts     []string
+	IncludeAccountNames []string
+	ExcludeAccountNames []string
+	IncludeRoles        []string
+	ExcludeRoles        []string
+	IncludeEmails       []string
+	ExcludeEmails       []string
+}
+
+var profileFilterFlags ProfileFilter
+
+// Matches reports whether the account/role combination passes the filter
+func (f ProfileFilter) Matches(ar AccountRole) bool {
+	return matchIncludeExclude(f.IncludeAccounts, f.ExcludeAccounts, ar.AccountID) &&
+		matchIncludeExclude(f.IncludeAccountNames, f.ExcludeAccountNames, ar.AccountName) &&
+		matchIncludeExclude(f.IncludeRoles, f.ExcludeRoles, ar.RoleName) &&
+		matchIncludeExclude(f.IncludeEmails, f.ExcludeEmails, ar.EmailAddress)
+}
+
+// IsEmpty reports whether the filter has no patterns at all
+func (f ProfileFilter) IsEmpty() bool {
+	return len(f.IncludeAccounts)+len(f.ExcludeAccounts)+
+		len(f.IncludeAccountNames)+len(f.ExcludeAccountNames)+
+		len(f.IncludeRoles)+len(f.ExcludeRoles)+
+		len(f.IncludeEmails)+len(f.ExcludeEmails) == 0
+}
+
+func matchIncludeExclude(include, exclude []string, value string) bool {
+	if len(include) > 0 && !matchAnyPattern(include, value) {
+		return false
+	}
+	return !matchAnyPattern(exclude, value)
+}
+
+// loadProfileFilter combines the [filters] section of the settings file with the command line flags
+func loadProfileFilter(settings *ini.File, flags ProfileFilter) (ProfileFilter, error) {
+	filter := ProfileFilter{
+		IncludeAccounts:     append(settingsList(settings, "filters", "include_accounts"), flags.IncludeAccounts...),
+		ExcludeAccounts:     append(settingsList(settings, "filters", "exclude_accounts"), flags.ExcludeAccounts...),
+		IncludeAccountNames: append(settingsList(settings, "filters", "include_account_names"), flags.IncludeAccountNames...),
+		ExcludeAccountNames: append(settingsList(settings, "filters", "exclude_account_names"), flags.ExcludeAccountNames...),
+		IncludeRoles:        append(settingsList(settings, "filters", "include_roles"), flags.IncludeRoles...),
+		ExcludeRoles:        append(settingsList(settings, "filters", "exclude_roles"), flags.ExcludeRoles...),
+		IncludeEmails:       append(settingsList(settings, "filters", "include_emails"), flags.IncludeEmails...),
+		ExcludeEmails:       append(settingsList(settings, "filters", "exclude_emails"), flags.ExcludeEmails...),
+	}
+	for _, patterns := range [][]string{
+		filter.IncludeAccounts, filter.ExcludeAccounts,
+		filter.IncludeAccountNames, filter.ExcludeAccountNames,
+		filter.IncludeRoles, filter.ExcludeRoles,
+		filter.IncludeEmails, filter.ExcludeEmails,
+	} {
+		if err := validatePatterns(patterns...); err != nil {
+			return ProfileFilter{}, fmt.Errorf("invalid profile filter: %w", err)
+		}
+	}
+	return filter, nil
+}
+
+func filterAccountRoles(accountRoles []AccountRole, filter ProfileFilter) []AccountRole {
+	var filtered []AccountRole
+	for _, ar := range accountRoles {
+		if filter.Matches(ar) {
+			filtered = append(filtered, ar)
+		}
+	}
+	return filtered
+}
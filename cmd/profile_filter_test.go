@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+func TestProfileFilter(t *testing.T) {
+	accountRoles := []AccountRole{
+		{AccountID: "111111111111", AccountName: "Team-A Dev", RoleName: "operator", EmailAddress: "team-a-dev@example.com"},
+		{AccountID: "111111111111", AccountName: "Team-A Dev", RoleName: "ReadOnly", EmailAddress: "team-a-dev@example.com"},
+		{AccountID: "222222222222", AccountName: "Team-B Prod", RoleName: "operator", EmailAddress: "team-b-prod@example.com"},
+		{AccountID: "333333333333", AccountName: "shared-prod", RoleName: "operator", EmailAddress: "platform@example.com"},
+	}
+
+	tests := []struct {
+		name   string
+		filter ProfileFilter
+		want   int
+	}{
+		{
+			name:   "empty filter keeps everything",
+			filter: ProfileFilter{},
+			want:   4,
+		},
+		{
+			name:   "include account id",
+			filter: ProfileFilter{IncludeAccounts: []string{"222222222222"}},
+			want:   1,
+		},
+		{
+			name:   "include account name glob is case insensitive",
+			filter: ProfileFilter{IncludeAccountNames: []string{"team-a*"}},
+			want:   2,
+		},
+		{
+			name:   "include account name regex",
+			filter: ProfileFilter{IncludeAccountNames: []string{"re:(?i)prod$"}},
+			want:   2,
+		},
+		{
+			name:   "exclude role",
+			filter: ProfileFilter{ExcludeRoles: []string{"readonly"}},
+			want:   3,
+		},
+		{
+			name:   "include and exclude combined",
+			filter: ProfileFilter{IncludeRoles: []string{"operator"}, ExcludeEmails: []string{"platform@*"}},
+			want:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterAccountRoles(accountRoles, tt.filter)
+			if len(got) != tt.want {
+				t.Errorf("Expected %d account roles, got %d: %v", tt.want, len(got), got)
+			}
+		})
+	}
+}
+
+func TestLoadProfileFilterMergesSettingsAndFlags(t *testing.T) {
+	settings, err := ini.LoadSources(ini.LoadOptions{KeyValueDelimiters: "="}, []byte(`
+[filters]
+include_account_names = team-*, re:^shared-
+exclude_roles = *readonly*
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse settings: %v", err)
+	}
+
+	filter, err := loadProfileFilter(settings, ProfileFilter{ExcludeRoles: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(filter.IncludeAccountNames) != 2 || filter.IncludeAccountNames[1] != "re:^shared-" {
+		t.Errorf("Unexpected include account names: %v", filter.IncludeAccountNames)
+	}
+	if len(filter.ExcludeRoles) != 2 {
+		t.Errorf("Expected settings and flag exclude roles to be merged, got %v", filter.ExcludeRoles)
+	}
+
+	if _, err := loadProfileFilter(settings, ProfileFilter{IncludeRoles: []string{"re:(admin"}}); err == nil || !strings.Contains(err.Error(), "re:(admin") {
+		t.Errorf("Expected an error naming the invalid pattern, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+)
+
+// profileNameCollision reports a profile name that several account/role combinations map to
+type profileNameCollision struct {
+	Name     string
+	Resolved []string
+}
+
+// resolveProfileNames returns the profile name of every account/role, in the same order.
+// Names shared by several combinations (e.g. accounts "Team.A" and "team a") get the account ID
+// appended to all of them, and a numeric suffix if that still isn't unique, so the result doesn't
+// depend on the order in which SSO returns the accounts.
+func resolveProfileNames(accountRoles []AccountRole) ([]string, []profileNameCollision) {
+	names := make([]string, len(accountRoles))
+	groups := make(map[string][]int)
+	for i, ar := range accountRoles {
+		names[i] = profileNameForAccountRole(ar)
+		groups[names[i]] = append(groups[names[i]], i)
+	}
+
+	var collided []string
+	for name, indexes := range groups {
+		if len(indexes) > 1 {
+			collided = append(collided, name)
+		}
+	}
+	sort.Strings(collided)
+
+	var collisions []profileNameCollision
+	for _, name := range collided {
+		indexes := groups[name]
+		sort.Slice(indexes, func(i, j int) bool {
+			a, b := accountRoles[indexes[i]], accountRoles[indexes[j]]
+			if a.AccountID == b.AccountID {
+				return a.RoleName < b.RoleName
+			}
+			return a.AccountID < b.AccountID
+		})
+
+		used := make(map[string]int)
+		for _, i := range indexes {
+			names[i] = fmt.Sprintf("%s-%s", name, accountRoles[i].AccountID)
+			used[names[i]]++
+		}
+		seen := make(map[string]int)
+		for _, i := range indexes {
+			if used[names[i]] > 1 {
+				base := names[i]
+				seen[base]++
+				names[i] = fmt.Sprintf("%s-%d", base, seen[base])
+			}
+		}
+
+		collision := profileNameCollision{Name: name}
+		for _, i := range indexes {
+			collision.Resolved = append(collision.Resolved, names[i])
+		}
+		collisions = append(collisions, collision)
+	}
+	return names, collisions
+}
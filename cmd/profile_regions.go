@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"gopkg.in/ini.v1"
+)
+
+var (
+	regionMapFlag    []string
+	detectRegionFlag bool
+	probeRegionsFlag []string
+)
+
+// defaultProbeRegions are the regions checked for EKS clusters when --detect-region is used
+var defaultProbeRegions = []string{
+	"eu-central-1", "eu-west-1", "eu-west-2", "eu-west-3", "eu-north-1",
+	"us-east-1", "us-east-2", "us-west-2",
+	"ap-southeast-1", "ap-southeast-2", "ap-northeast-1",
+}
+
+// RegionRule assigns a region to accounts whose ID or name matches the pattern
+type RegionRule struct {
+	Pattern string
+	Region  string
+}
+
+// regionDetector finds the region an account runs its EKS clusters in - can be mocked in tests
+var regionDetector = detectAccountRegion
+
+// loadRegionRules combines --region-map flags with the [regions] section of the settings file.
+// Flags take precedence, then the settings file in the order the keys are written.
+func loadRegionRules(settings *ini.File, flagValues []string) ([]RegionRule, error) {
+	var rules []RegionRule
+	for _, value := range flagValues {
+		pattern, region, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(pattern) == "" || strings.TrimSpace(region) == "" {
+			return nil, fmt.Errorf("invalid --region-map value %q, expected <account-id|name-pattern>=<region>", value)
+		}
+		rules = append(rules, RegionRule{Pattern: strings.TrimSpace(pattern), Region: strings.TrimSpace(region)})
+	}
+
+	if settings.HasSection("regions") {
+		for _, key := range settings.Section("regions").Keys() {
+			rules = append(rules, RegionRule{Pattern: key.Name(), Region: key.String()})
+		}
+	}
+	for _, rule := range rules {
+		if err := validatePatterns(rule.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid region rule: %w", err)
+		}
+	}
+	return rules, nil
+}
+
+// regionForAccount returns the region of the first rule matching the account ID or name
+func regionForAccount(rules []RegionRule, ar AccountRole) string {
+	for _, rule := range rules {
+		if matchPattern(rule.Pattern, ar.AccountID) || matchPattern(rule.Pattern, ar.AccountName) {
+			return rule.Region
+		}
+	}
+	return ""
+}
+
+// assignRegions sets the Region of every account/role from the rules, falling back to
+// region detection when enabled. Accounts without a match keep an empty region so the
+// default region is used.
+func assignRegions(ctx context.Context, accountRoles []AccountRole, rules []RegionRule, ssoClient *sso.Client, accessToken string) {
+	detected := make(map[string]string)
+
+	for i := range accountRoles {
+		ar := &accountRoles[i]
+		if region := regionForAccount(rules, *ar); region != "" {
+			ar.Region = region
+			continue
+		}
+		if !detectRegionFlag {
+			continue
+		}
+
+		region, probed := detected[ar.AccountID]
+		if !probed {
+			probeRegions := probeRegionsFlag
+			if len(probeRegions) == 0 {
+				probeRegions = defaultProbeRegions
+			}
+			var err error
+			region, err = regionDetector(ctx, ssoClient, accessToken, *ar, probeRegions)
+			if err != nil {
+				fmt.Printf("Warning: failed to detect region for account %s with role %s: %v\n", ar.AccountID, ar.RoleName, err)
+				continue
+			}
+			detected[ar.AccountID] = region
+			if region != "" {
+				fmt.Printf("Detected EKS clusters for account %s (%s) in %s\n", ar.AccountName, ar.AccountID, region)
+			}
+		}
+		ar.Region = region
+	}
+}
+
+// detectAccountRegion uses the SSO role credentials to look for EKS clusters in each of the
+// given regions and returns the first region containing at least one cluster
+func detectAccountRegion(ctx context.Context, ssoClient *sso.Client, accessToken string, ar AccountRole, regions []string) (string, error) {
+	roleCreds, err := ssoClient.GetRoleCredentials(ctx, &sso.GetRoleCredentialsInput{
+		AccessToken: aws.String(accessToken),
+		AccountId:   aws.String(ar.AccountID),
+		RoleName:    aws.String(ar.RoleName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get role credentials: %w", err)
+	}
+
+	creds := aws.Credentials{
+		AccessKeyID:     aws.ToString(roleCreds.RoleCredentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(roleCreds.RoleCredentials.SecretAccessKey),
+		SessionToken:    aws.ToString(roleCreds.RoleCredentials.SessionToken),
+		Source:          "SSORoleCredentials",
+	}
+	credsProvider := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return creds, nil
+	})
+
+	var lastErr error
+	probed := false
+	for _, region := range regions {
+		eksClient := eks.New(eks.Options{Region: region, Credentials: credsProvider})
+		out, err := eksClient.ListClusters(ctx, &eks.ListClustersInput{MaxResults: aws.Int32(1)})
+		if err != nil {
+			// The role may not be allowed to list clusters, or the region may be disabled
+			lastErr = err
+			continue
+		}
+		probed = true
+		if len(out.Clusters) > 0 {
+			return region, nil
+		}
+	}
+
+	if !probed && lastErr != nil {
+		return "", fmt.Errorf("failed to list EKS clusters: %w", lastErr)
+	}
+	return "", nil
+}
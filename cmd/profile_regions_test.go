@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"gopkg.in/ini.v1"
+)
+
+func TestLoadRegionRules(t *testing.T) {
+	settings, err := ini.LoadSources(ini.LoadOptions{KeyValueDelimiters: "="}, []byte(`
+[regions]
+123456789012 = us-east-1
+team-a-* = eu-west-1
+re:^shared- = eu-north-1
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse settings: %v", err)
+	}
+
+	rules, err := loadRegionRules(settings, []string{"team-a-prod=ap-southeast-1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		ar   AccountRole
+		want string
+	}{
+		{AccountRole{AccountID: "123456789012", AccountName: "Legacy"}, "us-east-1"},
+		{AccountRole{AccountID: "111111111111", AccountName: "team-a-dev"}, "eu-west-1"},
+		{AccountRole{AccountID: "111111111112", AccountName: "team-a-prod"}, "ap-southeast-1"},
+		{AccountRole{AccountID: "111111111113", AccountName: "shared-services"}, "eu-north-1"},
+		{AccountRole{AccountID: "111111111114", AccountName: "team-b"}, ""},
+	}
+	for _, tt := range tests {
+		if got := regionForAccount(rules, tt.ar); got != tt.want {
+			t.Errorf("regionForAccount(%s) = %q, want %q", tt.ar.AccountName, got, tt.want)
+		}
+	}
+
+	if _, err := loadRegionRules(settings, []string{"missing-region"}); err == nil {
+		t.Error("Expected an error for a --region-map value without region")
+	}
+	if _, err := loadRegionRules(settings, []string{"re:[team=eu-west-1"}); err == nil {
+		t.Error("Expected an error for an invalid regular expression")
+	}
+}
+
+func TestAssignRegionsDetectsOncePerAccount(t *testing.T) {
+	originalDetector := regionDetector
+	originalDetect := detectRegionFlag
+	defer func() {
+		regionDetector = originalDetector
+		detectRegionFlag = originalDetect
+	}()
+
+	calls := make(map[string]int)
+	regionDetector = func(ctx context.Context, ssoClient *sso.Client, accessToken string, ar AccountRole, regions []string) (string, error) {
+		calls[ar.AccountID]++
+		if ar.RoleName == "NoEKSAccess" {
+			return "", fmt.Errorf("access denied")
+		}
+		return "us-west-2", nil
+	}
+	detectRegionFlag = true
+
+	accountRoles := []AccountRole{
+		{AccountID: "111111111111", AccountName: "mapped", RoleName: "operator"},
+		{AccountID: "222222222222", AccountName: "detected", RoleName: "NoEKSAccess"},
+		{AccountID: "222222222222", AccountName: "detected", RoleName: "operator"},
+		{AccountID: "222222222222", AccountName: "detected", RoleName: "readonly"},
+	}
+	rules := []RegionRule{{Pattern: "mapped", Region: "eu-west-1"}}
+
+	assignRegions(context.Background(), accountRoles, rules, nil, "token")
+
+	want := []string{"eu-west-1", "", "us-west-2", "us-west-2"}
+	for i, ar := range accountRoles {
+		if ar.Region != want[i] {
+			t.Errorf("Account role %d (%s): expected region %q, got %q", i, ar.RoleName, want[i], ar.Region)
+		}
+	}
+	if calls["111111111111"] != 0 {
+		t.Errorf("Expected mapped account not to be probed, got %d calls", calls["111111111111"])
+	}
+	if calls["222222222222"] != 2 {
+		t.Errorf("Expected 2 probes for the detected account, got %d", calls["222222222222"])
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/eimarfandino/asp-eks/awsutils"
+	"github.com/spf13/cobra"
+)
+
+const defaultPromptFormat = `{{if .Profile}}aws:{{.Profile}}{{end}}` +
+	`{{if .Context}} k8s:{{.Context}}{{if .Namespace}} (ns:{{.Namespace}}){{end}}{{end}}` +
+	`{{if .SSOExpired}} sso:expired{{end}}`
+
+var (
+	promptFormat     string
+	promptShell      string
+	promptNoColor    bool
+	promptProduction []string
+)
+
+// promptInfo is what a prompt template can show. It is collected from the environment,
+// kubeconfig and SSO cache only, without network calls.
+type promptInfo struct {
+	Profile      string
+	Account      string
+	Context      string
+	Cluster      string
+	Region       string
+	Namespace    string
+	SSOExpired   bool
+	SSOExpiresIn string
+	Protected    bool
+}
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print a short profile and context segment for shell prompts",
+	Long: `Print the active AWS profile and kubeconfig context for a shell prompt, e.g.
+
+  aws:payments-prod k8s:payments-eks-1 (ns:api)
+
+It only reads the environment, kubeconfig, AWS config and SSO cache and makes no network calls,
+so it can run on every prompt render. Profiles matching a --production pattern, and profiles,
+accounts and context prefixes protected in ~/.asp-eks/config, are shown in bold red. The
+[protected] tag rules need the EKS API and are not checked here. Set NO_COLOR or --no-color to
+disable colours.
+
+--format takes a Go template with the fields .Profile, .Account, .Context, .Cluster, .Region,
+.Namespace, .SSOExpired, .SSOExpiresIn and .Protected. --shell zsh or bash wraps colour codes
+so the shell computes the prompt width correctly.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := validatePatterns(promptProduction...); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Invalid --production pattern:", err)
+			os.Exit(1)
+		}
+		tmpl, err := template.New("prompt").Parse(promptFormat)
+		if err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Invalid format:", err)
+			os.Exit(1)
+		}
+		color := !promptNoColor && os.Getenv("NO_COLOR") == ""
+		if err := renderPrompt(cmd.OutOrStdout(), tmpl, collectPromptInfo(), color, promptShell); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+	promptCmd.Flags().StringVar(&promptFormat, "format", defaultPromptFormat, "Go template for the prompt segment")
+	promptCmd.Flags().StringVar(&promptShell, "shell", "", "Escape colour codes for this shell's prompt: zsh or bash")
+	promptCmd.Flags().BoolVar(&promptNoColor, "no-color", false, "Don't colour protected targets")
+	promptCmd.Flags().StringArrayVar(&promptProduction, "production", nil, "Colour profiles matching this glob or re: pattern as production (repeatable)")
+}
+
+func collectPromptInfo() promptInfo {
+	info := promptInfo{Profile: os.Getenv("AWS_PROFILE")}
+
+	if info.Profile != "" {
+		if profileConfig, err := awsutils.GetProfileConfig(info.Profile); err == nil {
+			info.Account = profileConfig["sso_account_id"]
+		}
+		if chain, err := awsutils.GetProfileChain(info.Profile); err == nil {
+			if startURL, _, _, err := awsutils.GetProfileSSOSettings(chain[len(chain)-1].Name); err == nil && startURL != "" {
+				if token, err := findSSOCacheToken(startURL); err == nil {
+					remaining := time.Until(token.ExpiresAt)
+					info.SSOExpired = remaining <= 0
+					if !info.SSOExpired {
+						info.SSOExpiresIn = remaining.Round(time.Minute).String()
+					}
+				}
+			}
+		}
+	}
+
+	var clusterAccount string
+	if kubeConfig, err := loadKubeConfig(); err == nil && kubeConfig.CurrentContext != "" {
+		info.Context = kubeConfig.CurrentContext
+		if kubeContext := kubeConfig.Contexts[kubeConfig.CurrentContext]; kubeContext != nil {
+			info.Namespace = kubeContext.Namespace
+			if region, accountID, name, ok := parseClusterArn(kubeContext.Cluster); ok {
+				info.Region, clusterAccount, info.Cluster = region, accountID, name
+			}
+		}
+		if info.Account == "" {
+			info.Account = clusterAccount
+		}
+	}
+
+	info.Protected = info.Profile != "" && matchAnyPattern(promptProduction, info.Profile)
+	if settings, err := loadSettings(); err == nil {
+		rules, err := loadProtectionRules(settings)
+		if err != nil {
+			// Written to stderr so it shows up on every prompt without becoming part of it
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+		}
+		info.Protected = info.Protected || rules.Matches(info.Profile, info.Account, clusterAccount) ||
+			(rules.ContextPrefix != "" && strings.HasPrefix(info.Context, rules.ContextPrefix))
+	}
+	return info
+}
+
+// renderPrompt writes the prompt segment, in bold red for protected targets when colour is on
+func renderPrompt(w io.Writer, tmpl *template.Template, info promptInfo, color bool, shell string) error {
+	var segment strings.Builder
+	if err := tmpl.Execute(&segment, info); err != nil {
+		return err
+	}
+	text := strings.TrimSpace(segment.String())
+	if text == "" || !color || !info.Protected {
+		fmt.Fprintln(w, text)
+		return nil
+	}
+
+	start, reset := "\033[1;31m", "\033[0m"
+	switch shell {
+	case "zsh":
+		start, reset = "%{"+start+"%}", "%{"+reset+"%}"
+	case "bash":
+		// bash only honours \[ \] in PS1 itself, not in the output of a command substitution;
+		// there the raw \001 \002 markers it translates them to are needed
+		start, reset = "\001"+start+"\002", "\001"+reset+"\002"
+	}
+	fmt.Fprintln(w, start+text+reset)
+	return nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestCollectPromptInfo(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	t.Setenv("AWS_PROFILE", "payments-prod")
+
+	kubeConfigPath := filepath.Join(home, "kubeconfig")
+	os.WriteFile(kubeConfigPath, []byte(strings.Replace(testKubeConfig,
+		"    user: arn:aws:eks:eu-west-1:123456789012:cluster/payments-eks-1\n",
+		"    user: arn:aws:eks:eu-west-1:123456789012:cluster/payments-eks-1\n    namespace: api\n", 1)), 0600)
+	t.Setenv("KUBECONFIG", kubeConfigPath)
+
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[sso-session acme]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = eu-central-1
+
+[profile payments-prod]
+sso_session = acme
+sso_account_id = 123456789012
+sso_role_name = operator
+region = eu-west-1
+`), 0600)
+	writeSSOCacheFile(t, home, "acme.json", map[string]interface{}{
+		"startUrl":    "https://acme.awsapps.com/start",
+		"region":      "eu-central-1",
+		"accessToken": "token",
+		"expiresAt":   time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+	})
+	promptProduction = []string{"*-prod"}
+	defer func() { promptProduction = nil }()
+
+	info := collectPromptInfo()
+	if info.Profile != "payments-prod" || info.Context != "payments-eks-1" || info.Namespace != "api" || info.Account != "123456789012" {
+		t.Errorf("Unexpected prompt info %+v", info)
+	}
+	if !info.SSOExpired || !info.Protected {
+		t.Errorf("Expected an expired SSO login and a protected target, got %+v", info)
+	}
+
+	var output bytes.Buffer
+	tmpl := template.Must(template.New("prompt").Parse(defaultPromptFormat))
+	renderPrompt(&output, tmpl, info, false, "")
+	if got := output.String(); got != "aws:payments-prod k8s:payments-eks-1 (ns:api) sso:expired\n" {
+		t.Errorf("Unexpected prompt %q", got)
+	}
+}
+
+func TestRenderPromptColours(t *testing.T) {
+	tmpl := template.Must(template.New("prompt").Parse(defaultPromptFormat))
+	tests := []struct {
+		info  promptInfo
+		color bool
+		shell string
+		want  string
+	}{
+		{promptInfo{Context: "dev-eks"}, true, "", "k8s:dev-eks\n"},
+		{promptInfo{Profile: "prod", Protected: true}, false, "", "aws:prod\n"},
+		{promptInfo{Profile: "prod", Protected: true}, true, "", "\033[1;31maws:prod\033[0m\n"},
+		{promptInfo{Profile: "prod", Protected: true}, true, "zsh", "%{\033[1;31m%}aws:prod%{\033[0m%}\n"},
+		{promptInfo{Profile: "prod", Protected: true}, true, "bash", "\001\033[1;31m\002aws:prod\001\033[0m\002\n"},
+		{promptInfo{}, true, "", "\n"},
+	}
+	for _, tt := range tests {
+		var output bytes.Buffer
+		if err := renderPrompt(&output, tmpl, tt.info, tt.color, tt.shell); err != nil {
+			t.Fatal(err)
+		}
+		if output.String() != tt.want {
+			t.Errorf("renderPrompt(%+v, %v, %q) = %q, want %q", tt.info, tt.color, tt.shell, output.String(), tt.want)
+		}
+	}
+
+	var output bytes.Buffer
+	custom := template.Must(template.New("prompt").Parse("{{.Cluster}}@{{.Region}} {{if .Protected}}PROD{{end}}"))
+	renderPrompt(&output, custom, promptInfo{Cluster: "payments-eks-1", Region: "eu-west-1"}, false, "")
+	if output.String() != "payments-eks-1@eu-west-1\n" {
+		t.Errorf("Unexpected custom prompt %q", output.String())
+	}
+}
+
+func TestPromptCommandBashOutput(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	t.Setenv("AWS_PROFILE", "payments-prod")
+	t.Setenv("KUBECONFIG", filepath.Join(home, "kubeconfig"))
+	t.Setenv("NO_COLOR", "")
+	os.WriteFile(getSettingsPath(), []byte("[protected]\nprofiles = *-prod\n"), 0644)
+	defer func() { promptShell = "" }()
+
+	var output bytes.Buffer
+	rootCmd.SetOut(&output)
+	rootCmd.SetArgs([]string{"prompt", "--shell", "bash"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// PS1='$(asp-eks prompt --shell bash) \$ ' needs the raw \001 \002 markers, \[ \] would be printed
+	if got := output.String(); got != "\001\033[1;31m\002aws:payments-prod\001\033[0m\002\n" {
+		t.Errorf("Unexpected bash prompt %q", got)
+	}
+}
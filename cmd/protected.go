@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// protectionRules mark production targets, from the [protected] section of the settings file:
+//
+//	[protected]
+//	profiles = *-prod-*, re:^prod
+//	accounts = 123456789012
+//	tags = env=prod, criticality=high
+//	context_prefix = PROD-
+//
+// A target is protected when any rule matches. Switching to it with 'use' prints a banner and
+// asks to type the cluster name; with context_prefix its kubeconfig context gets that prefix.
+type protectionRules struct {
+	Profiles      []string
+	Accounts      []string
+	Tags          []string
+	ContextPrefix string
+}
+
+func loadProtectionRules(settings *ini.File) (protectionRules, error) {
+	rules := protectionRules{
+		Profiles: settingsList(settings, "protected", "profiles"),
+		Accounts: settingsList(settings, "protected", "accounts"),
+		Tags:     settingsList(settings, "protected", "tags"),
+	}
+	if settings.HasSection("protected") {
+		rules.ContextPrefix = strings.TrimSpace(settings.Section("protected").Key("context_prefix").String())
+	}
+	if err := validatePatterns(rules.Profiles...); err != nil {
+		return protectionRules{}, fmt.Errorf("invalid [protected] profiles: %w", err)
+	}
+	if err := validateTagFilters(rules.Tags); err != nil {
+		return protectionRules{}, fmt.Errorf("invalid [protected] tags: %w", err)
+	}
+	return rules, nil
+}
+
+// Matches reports whether the profile or one of the account IDs is protected
+func (r protectionRules) Matches(profile string, accountIDs ...string) bool {
+	return r.reason(profile, accountIDs, nil) != ""
+}
+
+// MatchesCluster returns why switching to the cluster with the profile is protected, or "" if it
+// isn't
+func (r protectionRules) MatchesCluster(profile string, clusterInfo *ClusterInfo) string {
+	_, accountID, _, _ := parseClusterArn(clusterInfo.Arn)
+	return r.reason(profile, []string{accountID}, clusterInfo.Tags)
+}
+
+func (r protectionRules) reason(profile string, accountIDs []string, tags map[string]string) string {
+	for _, pattern := range r.Profiles {
+		if profile != "" && matchPattern(pattern, profile) {
+			return fmt.Sprintf("profile matches %s", pattern)
+		}
+	}
+	for _, accountID := range accountIDs {
+		for _, protected := range r.Accounts {
+			if accountID != "" && accountID == protected {
+				return fmt.Sprintf("account %s", accountID)
+			}
+		}
+	}
+	for _, tag := range r.Tags {
+		if matchTags([]string{tag}, tags) {
+			return fmt.Sprintf("tag %s", tag)
+		}
+	}
+	return ""
+}
+
+// printProtectedBanner makes switching into a protected cluster hard to miss
+func printProtectedBanner(w io.Writer, profile string, clusterInfo *ClusterInfo, reason string) {
+	_, accountID, _, _ := parseClusterArn(clusterInfo.Arn)
+	lines := []string{
+		"PROTECTED CLUSTER: " + clusterInfo.Name,
+		fmt.Sprintf("account %s, region %s, profile %s", accountID, clusterInfo.Region, profile),
+		"protected because of " + reason,
+	}
+	width := 0
+	for _, line := range lines {
+		width = max(width, len(line))
+	}
+	border := strings.Repeat("!", width+8)
+	fmt.Fprintln(w, border)
+	for _, line := range lines {
+		fmt.Fprintf(w, "!!  %-*s  !!\n", width, line)
+	}
+	fmt.Fprintln(w, border)
+}
+
+// confirmProtected asks to type the cluster name before switching to a protected cluster
+func confirmProtected(w io.Writer, cluster string) error {
+	fmt.Fprintf(w, "Type the cluster name (%s) to continue: ", cluster)
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && input == "" {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if strings.TrimSpace(input) != cluster {
+		return errors.New("confirmation did not match, kubeconfig not changed")
+	}
+	return nil
+}
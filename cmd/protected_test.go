@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+func TestProtectionRulesMatchCluster(t *testing.T) {
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	os.WriteFile(getSettingsPath(), []byte(`[protected]
+profiles = *-prod-*
+accounts = 999999999999
+tags = env=prod*, criticality
+context_prefix = PROD-
+`), 0644)
+	settings, err := loadSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := loadProtectionRules(settings)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rules.ContextPrefix != "PROD-" {
+		t.Errorf("Expected context prefix PROD-, got %q", rules.ContextPrefix)
+	}
+
+	devArn := "arn:aws:eks:eu-west-1:123456789012:cluster/dev-eks"
+	tests := []struct {
+		profile string
+		cluster ClusterInfo
+		want    string
+	}{
+		{"payments-prod-operator", ClusterInfo{Arn: devArn}, "profile matches *-prod-*"},
+		{"payments-dev", ClusterInfo{Arn: "arn:aws:eks:eu-west-1:999999999999:cluster/x"}, "account 999999999999"},
+		{"payments-dev", ClusterInfo{Arn: devArn, Tags: map[string]string{"env": "production"}}, "tag env=prod*"},
+		{"payments-dev", ClusterInfo{Arn: devArn, Tags: map[string]string{"criticality": "low"}}, "tag criticality"},
+		{"payments-dev", ClusterInfo{Arn: devArn, Tags: map[string]string{"env": "staging"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := rules.MatchesCluster(tt.profile, &tt.cluster); got != tt.want {
+			t.Errorf("MatchesCluster(%s, %+v) = %q, want %q", tt.profile, tt.cluster, got, tt.want)
+		}
+	}
+}
+
+func TestLoadProtectionRulesRejectsInvalidPatterns(t *testing.T) {
+	for _, section := range []string{"profiles = re:^prod(", "tags = env=re:*prod"} {
+		settings, _ := ini.LoadSources(ini.LoadOptions{KeyValueDelimiters: "="}, []byte("[protected]\n"+section+"\n"))
+		if _, err := loadProtectionRules(settings); err == nil {
+			t.Errorf("Expected an error for %q", section)
+		}
+	}
+}
+
+func TestUseProtectedClusterRequiresConfirmation(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	os.WriteFile(getSettingsPath(), []byte("[protected]\nprofiles = *-prod-*\ncontext_prefix = PROD-\n"), 0644)
+
+	originalProvider := clusterProvider
+	clusterProvider = &mockClusterProvider{region: "eu-west-1"}
+	defer func() { clusterProvider = originalProvider }()
+
+	originalStdin := os.Stdin
+	defer func() { os.Stdin = originalStdin }()
+	var output bytes.Buffer
+	outputWriter = &output
+	defer func() { outputWriter = os.Stdout }()
+
+	kubeConfigPath := filepath.Join(home, ".kube", "config")
+	for _, tt := range []struct {
+		input   string
+		context string
+	}{
+		{"payments-eks\n", ""},
+		{"payments-eks-1\n", "PROD-payments-eks-1"},
+	} {
+		r, w, _ := os.Pipe()
+		w.Write([]byte(tt.input))
+		w.Close()
+		os.Stdin = r
+		output.Reset()
+
+		updateKubeconfig("payments-prod-operator", "payments-eks-1", false)
+
+		if !strings.Contains(output.String(), "!!  PROTECTED CLUSTER: payments-eks-1") {
+			t.Errorf("Expected a protected banner, got: %s", output.String())
+		}
+		data, _ := os.ReadFile(kubeConfigPath)
+		if tt.context == "" {
+			if len(data) > 0 || !strings.Contains(output.String(), "confirmation did not match") {
+				t.Errorf("Expected kubeconfig to stay untouched on a wrong confirmation, got: %s\n%s", output.String(), data)
+			}
+		} else if !strings.Contains(string(data), "current-context: "+tt.context) {
+			t.Errorf("Expected current context %s, got:\n%s", tt.context, data)
+		}
+	}
+
+	output.Reset()
+	updateKubeconfig("payments-dev-operator", "dev-eks", false)
+	if strings.Contains(output.String(), "PROTECTED") || !strings.Contains(output.String(), "Current context set to: dev-eks") {
+		t.Errorf("Expected an unprotected switch without confirmation, got: %s", output.String())
+	}
+}
+
+func TestCollectPromptInfoUsesProtectionRules(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	t.Setenv("AWS_PROFILE", "")
+
+	kubeConfigPath := filepath.Join(home, "kubeconfig")
+	os.WriteFile(kubeConfigPath, []byte(testKubeConfig), 0600)
+	t.Setenv("KUBECONFIG", kubeConfigPath)
+
+	os.WriteFile(getSettingsPath(), []byte("[protected]\naccounts = 999999999999\n"), 0644)
+	if info := collectPromptInfo(); info.Protected {
+		t.Errorf("Expected an unprotected account, got %+v", info)
+	}
+
+	os.WriteFile(getSettingsPath(), []byte("[protected]\naccounts = 123456789012\n"), 0644)
+	if info := collectPromptInfo(); !info.Protected {
+		t.Errorf("Expected the cluster's account to be protected, got %+v", info)
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// clusterRoleArn returns the role configured in the [roles] section of the settings file for
+// the cluster. Keys are cluster name patterns, or <profile>:<cluster> patterns:
+//
+//	[roles]
+//	payments-eks-* = arn:aws:iam::123456789012:role/cluster-admin
+//	shared-dev:tools-eks = arn:aws:iam::345678901234:role/tools-admin
+func clusterRoleArn(settings *ini.File, profile, cluster string) (string, error) {
+	if !settings.HasSection("roles") {
+		return "", nil
+	}
+	keys := settings.Section("roles").Keys()
+	for _, key := range keys {
+		if err := validatePatterns(key.Name()); err != nil {
+			return "", fmt.Errorf("invalid [roles] key: %w", err)
+		}
+	}
+	for _, key := range keys {
+		pattern, value := key.Name(), cluster
+		if !strings.HasPrefix(pattern, "re:") && strings.Contains(pattern, ":") {
+			value = profile + ":" + cluster
+		}
+		if matchPattern(pattern, value) {
+			return strings.TrimSpace(key.String()), nil
+		}
+	}
+	return "", nil
+}
+
+// withRoleOverride returns the cluster info with the token args assuming roleArn, or the
+// [roles] setting for the cluster when roleArn is empty
+func withRoleOverride(profile string, clusterInfo *ClusterInfo, roleArn string) (*ClusterInfo, error) {
+	if roleArn == "" {
+		settings, err := loadSettings()
+		if err != nil {
+			return nil, err
+		}
+		if roleArn, err = clusterRoleArn(settings, profile, clusterInfo.Name); err != nil {
+			return nil, err
+		}
+	}
+	if roleArn == "" {
+		return clusterInfo, nil
+	}
+	if !strings.HasPrefix(roleArn, "arn:") || !strings.Contains(roleArn, ":role/") {
+		return nil, fmt.Errorf("invalid role ARN %q", roleArn)
+	}
+
+	overridden := *clusterInfo
+	overridden.AuthArgs = append(append([]string{}, clusterInfo.AuthArgs...), "--role-arn", roleArn)
+	return &overridden, nil
+}
+
+// tokenRoleArn returns the role the cluster's token args assume, if any
+func tokenRoleArn(clusterInfo *ClusterInfo) string {
+	for i, arg := range clusterInfo.AuthArgs {
+		if arg == "--role-arn" && i+1 < len(clusterInfo.AuthArgs) {
+			return clusterInfo.AuthArgs[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClusterRoleArn(t *testing.T) {
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	os.WriteFile(getSettingsPath(), []byte(`[roles]
+shared-dev:tools-eks = arn:aws:iam::345678901234:role/tools-admin
+payments-eks-* = arn:aws:iam::123456789012:role/cluster-admin
+re:^batch-\d+$ = arn:aws:iam::123456789012:role/batch-admin
+`), 0644)
+	settings, err := loadSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		profile, cluster, want string
+	}{
+		{"shared-dev", "tools-eks", "arn:aws:iam::345678901234:role/tools-admin"},
+		{"other", "tools-eks", ""},
+		{"payments-prod-operator", "payments-eks-1", "arn:aws:iam::123456789012:role/cluster-admin"},
+		{"payments-prod-operator", "batch-42", "arn:aws:iam::123456789012:role/batch-admin"},
+		{"payments-prod-operator", "batch-x", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := clusterRoleArn(settings, tt.profile, tt.cluster); got != tt.want {
+			t.Errorf("clusterRoleArn(%s, %s) = %q, want %q", tt.profile, tt.cluster, got, tt.want)
+		}
+	}
+
+	settings.Section("roles").Key("re:batch-(").SetValue("arn:aws:iam::123456789012:role/batch-admin")
+	if _, err := clusterRoleArn(settings, "p", "tools-eks"); err == nil {
+		t.Error("Expected an error for an invalid [roles] pattern")
+	}
+}
+
+func TestWithRoleOverride(t *testing.T) {
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	clusterInfo := &ClusterInfo{Name: "payments-eks-1", AuthArgs: []string{"eks", "get-token", "--cluster-name", "payments-eks-1"}}
+
+	overridden, err := withRoleOverride("p", clusterInfo, "arn:aws:iam::123456789012:role/cluster-admin")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := strings.Join(overridden.AuthArgs, " "); got != "eks get-token --cluster-name payments-eks-1 --role-arn arn:aws:iam::123456789012:role/cluster-admin" {
+		t.Errorf("Unexpected token args %s", got)
+	}
+	if len(clusterInfo.AuthArgs) != 4 {
+		t.Errorf("Expected the original cluster info to be unchanged, got %v", clusterInfo.AuthArgs)
+	}
+
+	if unchanged, err := withRoleOverride("p", clusterInfo, ""); err != nil || unchanged != clusterInfo {
+		t.Errorf("Expected no override without a role, got %v, %v", unchanged, err)
+	}
+	if _, err := withRoleOverride("p", clusterInfo, "cluster-admin"); err == nil {
+		t.Error("Expected an error for an invalid role ARN")
+	}
+}
+
+func TestUseWithRoleArn(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+
+	originalProvider := clusterProvider
+	clusterProvider = &mockClusterProvider{region: "eu-west-1"}
+	defer func() { clusterProvider = originalProvider }()
+
+	var output bytes.Buffer
+	outputWriter = &output
+	defer func() { outputWriter = os.Stdout }()
+	roleArnFlag = "arn:aws:iam::123456789012:role/cluster-admin"
+	defer func() { roleArnFlag = "" }()
+
+	updateKubeconfig("payments-prod-operator", "payments-eks-1", false)
+
+	data, _ := os.ReadFile(filepath.Join(home, ".kube", "config"))
+	if !strings.Contains(string(data), "- --role-arn\n      - arn:aws:iam::123456789012:role/cluster-admin") {
+		t.Errorf("Expected the role in the token args, got:\n%s", data)
+	}
+	if !strings.Contains(output.String(), "kubectl will authenticate as arn:aws:iam::123456789012:role/cluster-admin") {
+		t.Errorf("Expected the role to be reported, got: %s", output.String())
+	}
+}
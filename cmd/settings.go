@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/ini.v1"
+)
+
+// getSettingsDir returns the directory where asp-eks keeps its own configuration.
+// It can be overridden with the ASP_EKS_HOME environment variable.
+func getSettingsDir() string {
+	if dir := os.Getenv("ASP_EKS_HOME"); dir != "" {
+		return dir
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".asp-eks")
+}
+
+func getSettingsPath() string {
+	dir := getSettingsDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "config")
+}
+
+// loadSettings loads the asp-eks settings file, returning an empty file if it doesn't exist.
+// Only "=" is accepted as key/value delimiter so keys may contain ":" (e.g. regex patterns).
+func loadSettings() (*ini.File, error) {
+	opts := ini.LoadOptions{KeyValueDelimiters: "="}
+
+	settingsPath := getSettingsPath()
+	if settingsPath == "" {
+		return ini.LoadSources(opts, []byte{})
+	}
+	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
+		return ini.LoadSources(opts, []byte{})
+	}
+
+	f, err := ini.LoadSources(opts, settingsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings file %s: %w", settingsPath, err)
+	}
+	return f, nil
+}
+
+// settingsList returns a comma separated settings value as a trimmed list
+func settingsList(f *ini.File, section, key string) []string {
+	if !f.HasSection(section) {
+		return nil
+	}
+	var values []string
+	for _, v := range strings.Split(f.Section(section).Key(key).String(), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// compiledPatterns caches the regular expressions of "re:" patterns by expression
+var compiledPatterns sync.Map
+
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := compiledPatterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	compiledPatterns.Store(expr, re)
+	return re, nil
+}
+
+// validatePatterns returns an error for the first pattern that is not a valid regular expression
+// or glob. Patterns are validated when settings and flags are loaded, so a typo is reported
+// instead of silently matching nothing.
+func validatePatterns(patterns ...string) error {
+	for _, pattern := range patterns {
+		var err error
+		if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+			_, err = compilePattern(expr)
+		} else {
+			_, err = path.Match(strings.ToLower(pattern), "")
+		}
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchPattern reports whether value matches pattern. Patterns prefixed with "re:" are
+// regular expressions, everything else is a case-insensitive glob. Invalid patterns match
+// nothing, validatePatterns reports them.
+func matchPattern(pattern, value string) bool {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		re, err := compilePattern(expr)
+		return err == nil && re.MatchString(value)
+	}
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && matched
+}
+
+// matchAnyPattern reports whether value matches at least one of the patterns
+func matchAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// saveSettings writes the settings file, creating the settings directory if needed
+func saveSettings(f *ini.File) error {
+	settingsPath := getSettingsPath()
+	if settingsPath == "" {
+		return fmt.Errorf("could not determine settings path")
+	}
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(settingsPath), err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		return fmt.Errorf("failed to render settings: %w", err)
+	}
+	return writeFileAtomically(settingsPath, buf.Bytes())
+}
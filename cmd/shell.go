@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell <profile[:cluster]|alias>",
+	Short: "Start a subshell scoped to a profile and cluster",
+	Long: `Ensure the SSO login of the profile and start $SHELL with AWS_PROFILE, AWS_REGION and a private
+KUBECONFIG for the cluster. Other terminals and ~/.kube/config are not affected, and the private
+kubeconfig is removed when the shell exits.
+
+The subshell is marked with ASP_EKS_PROFILE and ASP_EKS_CONTEXT, e.g. to show them in the prompt.
+Starting a shell from inside another asp-eks shell prints a warning.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exitCode, err := runScopedShell(context.Background(), cmd, args[0])
+		if err != nil {
+			fmt.Fprintln(outputWriter, "Error:", err)
+			exitCode = 1
+		}
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
+
+// runScopedShell starts the user's shell for the target and returns its exit code
+func runScopedShell(ctx context.Context, cmd *cobra.Command, target string) (int, error) {
+	if current := os.Getenv("ASP_EKS_PROFILE"); current != "" {
+		fmt.Fprintf(outputWriter, "Warning: already in an asp-eks shell for %s (%s), starting a nested shell. Type 'exit' to leave it.\n",
+			current, os.Getenv("ASP_EKS_CONTEXT"))
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		return 0, err
+	}
+	profile, cluster := resolveTarget(loadAliases(settings), target)
+
+	if err := ensureSSO(profile); err != nil {
+		return 0, fmt.Errorf("failed to ensure SSO login: %w", err)
+	}
+
+	clusterInfo, err := resolveClusterInfo(ctx, profile, cluster, func(clusters []string) (string, error) {
+		region, _ := clusterProvider.GetRegion(ctx, profile)
+		return pickCluster(profile, region, clusters, nil)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "asp-eks-shell-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	kubeConfigPath := filepath.Join(tempDir, "config")
+	if err := writeKubeContext(kubeConfigPath, clusterInfo); err != nil {
+		return 0, err
+	}
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	process := execCommand(shell)
+	process.Env = append(clusterEnv(process.Env, profile, clusterInfo.Region, kubeConfigPath),
+		"ASP_EKS_PROFILE="+profile,
+		"ASP_EKS_CONTEXT="+clusterInfo.Name,
+	)
+	process.Stdin = os.Stdin
+	process.Stdout = cmd.OutOrStdout()
+	process.Stderr = cmd.ErrOrStderr()
+
+	fmt.Fprintf(outputWriter, "Starting %s for %s / %s, type 'exit' to leave\n", shell, profile, clusterInfo.Name)
+	err = runInForeground(process)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to start %s: %w", shell, err)
+	}
+	fmt.Fprintf(outputWriter, "Left asp-eks shell for %s / %s\n", profile, clusterInfo.Name)
+	return 0, nil
+}
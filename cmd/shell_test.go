@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestShellCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ASP_EKS_HOME", t.TempDir())
+	t.Setenv("SHELL", "print-shell-env")
+	t.Setenv("ASP_EKS_PROFILE", "shared-dev-operator")
+	t.Setenv("ASP_EKS_CONTEXT", "tools-eks")
+
+	originalProvider := clusterProvider
+	clusterProvider = &mockClusterProvider{region: "eu-west-1", clusters: []string{"payments-eks-1", "payments-eks-2"}}
+	defer func() { clusterProvider = originalProvider }()
+
+	originalCredentialsValidator := credentialsValidator
+	credentialsValidator = func(ctx context.Context, profile string) bool { return true }
+	defer func() { credentialsValidator = originalCredentialsValidator }()
+
+	execCommand = fakeExecCommand
+	defer func() { execCommand = exec.Command }()
+
+	// Select the second cluster in the picker
+	r, w, _ := os.Pipe()
+	originalStdin := os.Stdin
+	os.Stdin = r
+	w.Write([]byte("2\n"))
+	w.Close()
+	defer func() { os.Stdin = originalStdin }()
+
+	var output bytes.Buffer
+	outputWriter = &output
+	defer func() { outputWriter = os.Stdout }()
+	rootCmd.SetOut(&output)
+	rootCmd.SetArgs([]string{"shell", "payments-prod-operator"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got := output.String()
+	if !strings.Contains(got, "Warning: already in an asp-eks shell for shared-dev-operator (tools-eks)") {
+		t.Errorf("Expected a nested shell warning, got: %s", got)
+	}
+	if !strings.Contains(got, "ASP_EKS_PROFILE=payments-prod-operator ASP_EKS_CONTEXT=payments-eks-2 KUBECONFIG=") {
+		t.Errorf("Expected the shell to be marked with profile and context, got: %s", got)
+	}
+	if !strings.Contains(got, "Left asp-eks shell for payments-prod-operator / payments-eks-2") {
+		t.Errorf("Expected a message when leaving the shell, got: %s", got)
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+const defaultSSOName = "DEFAULT-SSO"
+
+// baseSSOConfig names the sso-session and the base profile used for "aws sso login"
+type baseSSOConfig struct {
+	ProfileName string
+	SessionName string
+	RoleName    string
+}
+
+var baseSSOFlags baseSSOConfig
+
+// loadBaseSSOConfig resolves the base SSO configuration from flags, then the [sso] section of
+// the settings file, then the defaults
+func loadBaseSSOConfig(settings *ini.File, flags baseSSOConfig) baseSSOConfig {
+	pick := func(flagValue, key, fallback string) string {
+		if flagValue != "" {
+			return flagValue
+		}
+		if settings.HasSection("sso") {
+			if value := settings.Section("sso").Key(key).String(); value != "" {
+				return value
+			}
+		}
+		return fallback
+	}
+
+	return baseSSOConfig{
+		ProfileName: pick(flags.ProfileName, "profile_name", defaultSSOName),
+		SessionName: pick(flags.SessionName, "session_name", defaultSSOName),
+		RoleName:    pick(flags.RoleName, "role_name", ""),
+	}
+}
+
+// ensureBaseProfileRole completes a base SSO profile without sso_role_name. The configured role
+// is used when set, otherwise the user picks one of the roles discovered after login. The profile
+// also gets the first account offering that role so it can be used for more than just login.
+func ensureBaseProfileRole(base baseSSOConfig, accountRoles []AccountRole) error {
+	configPath, err := getAwsConfigPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read AWS config file: %w", err)
+	}
+
+	unmanaged, _, err := splitManagedBlock(string(data))
+	if err != nil {
+		return err
+	}
+	profileSection := "profile " + base.ProfileName
+	current := sectionKeys(unmanaged, profileSection)
+	if current == nil || current["sso_role_name"] != "" {
+		return nil
+	}
+
+	roleName := base.RoleName
+	if roleName == "" {
+		roleName, err = selectSSORole(accountRoles)
+		if err != nil {
+			return fmt.Errorf("base profile %s has no role configured: %w", base.ProfileName, err)
+		}
+	}
+
+	keys := map[string]string{"sso_role_name": roleName}
+	if current["sso_account_id"] == "" {
+		for _, ar := range accountRoles {
+			if ar.RoleName == roleName {
+				keys["sso_account_id"] = ar.AccountID
+				break
+			}
+		}
+	}
+
+	if err := updateUnmanagedConfig(configPath, func(content string) string {
+		return setSectionKeys(content, profileSection, keys)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Configured [%s] with role %s\n", profileSection, roleName)
+	return nil
+}
+
+// selectSSORole lets the user pick one of the distinct role names available through SSO
+func selectSSORole(accountRoles []AccountRole) (string, error) {
+	seen := make(map[string]bool)
+	var roles []string
+	for _, ar := range accountRoles {
+		if !seen[ar.RoleName] {
+			seen[ar.RoleName] = true
+			roles = append(roles, ar.RoleName)
+		}
+	}
+	sort.Strings(roles)
+
+	if len(roles) == 0 {
+		return "", fmt.Errorf("no roles available")
+	}
+	if len(roles) == 1 {
+		return roles[0], nil
+	}
+
+	fmt.Println("Available roles for the base SSO profile:")
+	for i, role := range roles {
+		fmt.Printf("[%d] %s\n", i+1, role)
+	}
+	fmt.Print("Select role by number: ")
+
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("error reading input: %w", err)
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || choice < 1 || choice > len(roles) {
+		return "", fmt.Errorf("invalid selection")
+	}
+	return roles[choice-1], nil
+}
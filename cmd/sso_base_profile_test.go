@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+func TestLoadBaseSSOConfig(t *testing.T) {
+	settings, err := ini.LoadSources(ini.LoadOptions{KeyValueDelimiters: "="}, []byte(`
+[sso]
+profile_name = acme-sso
+role_name = PlatformEngineer
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse settings: %v", err)
+	}
+
+	base := loadBaseSSOConfig(settings, baseSSOConfig{RoleName: "Admin"})
+	if base.ProfileName != "acme-sso" {
+		t.Errorf("Expected profile name from settings, got %q", base.ProfileName)
+	}
+	if base.SessionName != defaultSSOName {
+		t.Errorf("Expected default session name, got %q", base.SessionName)
+	}
+	if base.RoleName != "Admin" {
+		t.Errorf("Expected role name from flag, got %q", base.RoleName)
+	}
+}
+
+func TestCreateDefaultSSOConfigurationAndRoleSelection(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configPath := filepath.Join(home, ".aws", "config")
+
+	base := baseSSOConfig{ProfileName: "acme-sso", SessionName: "acme"}
+	if err := createDefaultSSOConfiguration(configPath, "https://acme.awsapps.com/start", base); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, _ := os.ReadFile(configPath)
+	content := string(data)
+	for _, want := range []string{"[sso-session acme]", "sso_start_url = https://acme.awsapps.com/start", "[profile acme-sso]", "sso_session = acme"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected config to contain %q, got:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "sso_role_name") || strings.Contains(content, "itfrun-operator") {
+		t.Errorf("Expected no role without --sso-role, got:\n%s", content)
+	}
+
+	// Pick the second role from the discovered roles
+	r, w, _ := os.Pipe()
+	originalStdin := os.Stdin
+	os.Stdin = r
+	w.Write([]byte("2\n"))
+	w.Close()
+	defer func() { os.Stdin = originalStdin }()
+
+	accountRoles := []AccountRole{
+		{AccountID: "111111111111", AccountName: "a", RoleName: "Admin"},
+		{AccountID: "111111111111", AccountName: "a", RoleName: "ReadOnly"},
+		{AccountID: "222222222222", AccountName: "b", RoleName: "ReadOnly"},
+	}
+	if err := ensureBaseProfileRole(base, accountRoles); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, _ = os.ReadFile(configPath)
+	keys := sectionKeys(string(data), "profile acme-sso")
+	if keys["sso_role_name"] != "ReadOnly" || keys["sso_account_id"] != "111111111111" {
+		t.Errorf("Expected ReadOnly role in account 111111111111, got %v", keys)
+	}
+	if keys["sso_session"] != "acme" {
+		t.Errorf("Expected existing keys to be kept, got %v", keys)
+	}
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+)
+
+// SSOCacheToken is the token the AWS CLI caches in ~/.aws/sso/cache after "aws sso login".
+// Logins through an sso-session also store the OIDC client registration and a refresh token.
+type SSOCacheToken struct {
+	AccessToken           string    `json:"accessToken"`
+	ExpiresAt             time.Time `json:"expiresAt"`
+	Region                string    `json:"region"`
+	StartURL              string    `json:"startUrl"`
+	RefreshToken          string    `json:"refreshToken,omitempty"`
+	ClientID              string    `json:"clientId,omitempty"`
+	ClientSecret          string    `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt time.Time `json:"registrationExpiresAt"`
+}
+
+// CanRefresh reports whether the token holds a refresh token and a client registration that
+// hasn't expired yet
+func (t *SSOCacheToken) CanRefresh() bool {
+	if t.RefreshToken == "" || t.ClientID == "" || t.ClientSecret == "" || t.Region == "" {
+		return false
+	}
+	return t.RegistrationExpiresAt.IsZero() || time.Now().Before(t.RegistrationExpiresAt)
+}
+
+// ssoTokenRefresher exchanges the refresh token for a new access token - can be mocked in tests
+var ssoTokenRefresher = refreshSSOToken
+
+func getSSOCacheDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".aws", "sso", "cache"), nil
+}
+
+func getSSOAccessToken(ctx context.Context, startURL, region string) (string, error) {
+	// Check for cached tokens in ~/.aws/sso/cache/
+	cacheDir, err := getSSOCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	// List all cache files
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read SSO cache directory. Please run 'aws sso login' first: %w", err)
+	}
+
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".json") {
+			cachePath := filepath.Join(cacheDir, entry.Name())
+			token, err := readTokenFromCache(ctx, cachePath, startURL)
+			if err == nil && token != "" {
+				return token, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no valid SSO token found. Please run 'aws sso login' first")
+}
+
+// readTokenFromCache returns the access token of a cache file for the start URL. Expired tokens
+// are silently refreshed when the cache file holds a refresh token, updating the file in place.
+func readTokenFromCache(ctx context.Context, cachePath, startURL string) (string, error) {
+	token, err := loadSSOCacheToken(cachePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Check if this cache file is for the correct start URL
+	if token.StartURL != startURL {
+		return "", fmt.Errorf("cache file doesn't match start URL")
+	}
+
+	// Check if token is expired
+	if time.Now().Before(token.ExpiresAt) {
+		return token.AccessToken, nil
+	}
+	if !token.CanRefresh() {
+		return "", fmt.Errorf("token is expired")
+	}
+
+	refreshed, err := ssoTokenRefresher(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("token is expired and could not be refreshed: %w", err)
+	}
+	if err := updateSSOCacheFile(cachePath, refreshed); err != nil {
+		return "", err
+	}
+	return refreshed.AccessToken, nil
+}
+
+func loadSSOCacheToken(cachePath string) (*SSOCacheToken, error) {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var token SSOCacheToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse cache file: %w", err)
+	}
+	return &token, nil
+}
+
+// refreshSSOToken calls SSO OIDC CreateToken with the refresh_token grant
+func refreshSSOToken(ctx context.Context, token *SSOCacheToken) (*SSOCacheToken, error) {
+	oidcClient := ssooidc.New(ssooidc.Options{Region: token.Region})
+	out, err := oidcClient.CreateToken(ctx, &ssooidc.CreateTokenInput{
+		ClientId:     aws.String(token.ClientID),
+		ClientSecret: aws.String(token.ClientSecret),
+		GrantType:    aws.String("refresh_token"),
+		RefreshToken: aws.String(token.RefreshToken),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed := *token
+	refreshed.AccessToken = aws.ToString(out.AccessToken)
+	refreshed.ExpiresAt = time.Now().UTC().Add(time.Duration(out.ExpiresIn) * time.Second)
+	if out.RefreshToken != nil {
+		refreshed.RefreshToken = *out.RefreshToken
+	}
+	return &refreshed, nil
+}
+
+// updateSSOCacheFile writes the refreshed token fields back to the cache file, keeping any
+// other fields written by the AWS CLI
+func updateSSOCacheFile(cachePath string, token *SSOCacheToken) error {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse cache file: %w", err)
+	}
+	raw["accessToken"] = token.AccessToken
+	raw["expiresAt"] = token.ExpiresAt.UTC().Format(time.RFC3339)
+	raw["refreshToken"] = token.RefreshToken
+
+	updated, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache file: %w", err)
+	}
+	if err := writeFileAtomically(cachePath, updated); err != nil {
+		return fmt.Errorf("failed to update cache file: %w", err)
+	}
+	return nil
+}
+
+// findSSOCacheToken returns the cached token for the start URL with the latest expiry
+func findSSOCacheToken(startURL string) (*SSOCacheToken, error) {
+	cacheDir, err := getSSOCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSO cache directory: %w", err)
+	}
+
+	var found *SSOCacheToken
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		token, err := loadSSOCacheToken(filepath.Join(cacheDir, entry.Name()))
+		if err != nil || token.StartURL != startURL || token.AccessToken == "" {
+			continue
+		}
+		if found == nil || token.ExpiresAt.After(found.ExpiresAt) {
+			found = token
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("no cached SSO token for %s", startURL)
+	}
+	return found, nil
+}
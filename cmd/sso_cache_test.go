@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSSOCacheFile(t *testing.T, home, name string, content map[string]interface{}) string {
+	t.Helper()
+	cacheDir := filepath.Join(home, ".aws", "sso", "cache")
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		t.Fatalf("Failed to create cache dir: %v", err)
+	}
+	data, _ := json.Marshal(content)
+	cachePath := filepath.Join(cacheDir, name)
+	if err := os.WriteFile(cachePath, data, 0600); err != nil {
+		t.Fatalf("Failed to write cache file: %v", err)
+	}
+	return cachePath
+}
+
+func TestGetSSOAccessTokenRefreshesExpiredToken(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	startURL := "https://test.awsapps.com/start"
+	cachePath := writeSSOCacheFile(t, home, "session.json", map[string]interface{}{
+		"startUrl":              startURL,
+		"region":                "eu-central-1",
+		"accessToken":           "expired-token",
+		"expiresAt":             time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+		"clientId":              "client-id",
+		"clientSecret":          "client-secret",
+		"registrationExpiresAt": time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		"refreshToken":          "refresh-token",
+	})
+
+	originalRefresher := ssoTokenRefresher
+	defer func() { ssoTokenRefresher = originalRefresher }()
+	ssoTokenRefresher = func(ctx context.Context, token *SSOCacheToken) (*SSOCacheToken, error) {
+		if token.RefreshToken != "refresh-token" || token.ClientID != "client-id" {
+			return nil, fmt.Errorf("unexpected token %+v", token)
+		}
+		refreshed := *token
+		refreshed.AccessToken = "fresh-token"
+		refreshed.ExpiresAt = time.Now().Add(time.Hour)
+		refreshed.RefreshToken = "new-refresh-token"
+		return &refreshed, nil
+	}
+
+	token, err := getSSOAccessToken(context.Background(), startURL, "eu-central-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token != "fresh-token" {
+		t.Errorf("Expected refreshed token, got %q", token)
+	}
+
+	cached, err := loadSSOCacheToken(cachePath)
+	if err != nil {
+		t.Fatalf("Failed to read cache file: %v", err)
+	}
+	if cached.AccessToken != "fresh-token" || cached.RefreshToken != "new-refresh-token" || cached.ClientSecret != "client-secret" {
+		t.Errorf("Expected cache file to be updated in place, got %+v", cached)
+	}
+	if !time.Now().Before(cached.ExpiresAt) {
+		t.Errorf("Expected cached token to be valid, expires at %v", cached.ExpiresAt)
+	}
+}
+
+func TestGetSSOAccessTokenWithoutRefreshToken(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	startURL := "https://test.awsapps.com/start"
+	writeSSOCacheFile(t, home, "legacy.json", map[string]interface{}{
+		"startUrl":    startURL,
+		"region":      "eu-central-1",
+		"accessToken": "expired-token",
+		"expiresAt":   time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	})
+
+	originalRefresher := ssoTokenRefresher
+	defer func() { ssoTokenRefresher = originalRefresher }()
+	ssoTokenRefresher = func(ctx context.Context, token *SSOCacheToken) (*SSOCacheToken, error) {
+		t.Error("Expected no refresh without a refresh token")
+		return nil, fmt.Errorf("unexpected refresh")
+	}
+
+	if _, err := getSSOAccessToken(context.Background(), startURL, "eu-central-1"); err == nil {
+		t.Error("Expected an error for an expired token without refresh token")
+	}
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/eimarfandino/asp-eks/awsutils"
+	"github.com/spf13/cobra"
+)
+
+var statusOutput string
+
+// callerIdentityFetcher returns the account and ARN of a profile - can be mocked in tests
+var callerIdentityFetcher = getCallerIdentity
+
+// statusReport describes the current AWS and Kubernetes state of the shell
+type statusReport struct {
+	Profile        string     `json:"profile"`
+	AccountID      string     `json:"accountId,omitempty"`
+	Arn            string     `json:"arn,omitempty"`
+	IdentityError  string     `json:"identityError,omitempty"`
+	SSOExpiresAt   *time.Time `json:"ssoExpiresAt,omitempty"`
+	SSOExpired     bool       `json:"ssoExpired"`
+	Context        string     `json:"context,omitempty"`
+	ClusterArn     string     `json:"clusterArn,omitempty"`
+	ClusterRegion  string     `json:"clusterRegion,omitempty"`
+	ContextProfile string     `json:"contextProfile,omitempty"`
+	ProfileMatches bool       `json:"profileMatches"`
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the current AWS identity, kubeconfig context and credential expiry",
+	Long: `Show the active AWS_PROFILE, its STS caller identity, the expiry of the cached SSO token,
+the current kubeconfig context with its cluster, and whether the context uses the same
+profile as the shell.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if statusOutput != "text" && statusOutput != "json" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Unsupported output format %q, use text or json\n", statusOutput)
+			os.Exit(1)
+		}
+
+		report := collectStatus(context.Background())
+
+		if statusOutput == "json" {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			encoder.Encode(report)
+			return
+		}
+		printStatus(cmd.OutOrStdout(), report)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().StringVarP(&statusOutput, "output", "o", "text", "Output format: text or json")
+}
+
+func collectStatus(ctx context.Context) statusReport {
+	report := statusReport{Profile: os.Getenv("AWS_PROFILE")}
+
+	profile := report.Profile
+	if profile == "" {
+		profile = "default"
+	}
+
+	accountID, arn, err := callerIdentityFetcher(ctx, profile)
+	if err != nil {
+		report.IdentityError = err.Error()
+	} else {
+		report.AccountID = accountID
+		report.Arn = arn
+	}
+
+	if startURL, _, _, err := awsutils.GetProfileSSOSettings(profile); err == nil && startURL != "" {
+		if token, err := findSSOCacheToken(startURL); err == nil {
+			expiresAt := token.ExpiresAt
+			report.SSOExpiresAt = &expiresAt
+			report.SSOExpired = !time.Now().Before(expiresAt)
+		}
+	}
+
+	if kubeConfig, err := loadKubeConfig(); err == nil && kubeConfig.CurrentContext != "" {
+		report.Context = kubeConfig.CurrentContext
+		if kubeContext := kubeConfig.Contexts[kubeConfig.CurrentContext]; kubeContext != nil {
+			report.ClusterArn = kubeContext.Cluster
+			if region, _, _, ok := parseClusterArn(kubeContext.Cluster); ok {
+				report.ClusterRegion = region
+			}
+		}
+		report.ContextProfile = contextProfile(kubeConfig, kubeConfig.CurrentContext)
+	}
+	report.ProfileMatches = report.ContextProfile != "" && report.ContextProfile == report.Profile
+
+	return report
+}
+
+func printStatus(w io.Writer, report statusReport) {
+	profile := report.Profile
+	if profile == "" {
+		profile = "(not set, using default)"
+	}
+	fmt.Fprintf(w, "AWS profile:     %s\n", profile)
+
+	if report.IdentityError != "" {
+		fmt.Fprintf(w, "Identity:        unavailable (%s)\n", report.IdentityError)
+	} else {
+		fmt.Fprintf(w, "Account:         %s\n", report.AccountID)
+		fmt.Fprintf(w, "Identity:        %s\n", report.Arn)
+	}
+
+	switch {
+	case report.SSOExpiresAt == nil:
+		fmt.Fprintln(w, "SSO token:       not found")
+	case report.SSOExpired:
+		fmt.Fprintf(w, "SSO token:       expired at %s\n", report.SSOExpiresAt.Local().Format(time.RFC1123))
+	default:
+		fmt.Fprintf(w, "SSO token:       expires at %s (in %s)\n", report.SSOExpiresAt.Local().Format(time.RFC1123),
+			time.Until(*report.SSOExpiresAt).Round(time.Minute))
+	}
+
+	if report.Context == "" {
+		fmt.Fprintln(w, "Kube context:    none")
+		return
+	}
+	fmt.Fprintf(w, "Kube context:    %s\n", report.Context)
+	fmt.Fprintf(w, "Cluster:         %s\n", report.ClusterArn)
+	if report.ClusterRegion != "" {
+		fmt.Fprintf(w, "Cluster region:  %s\n", report.ClusterRegion)
+	}
+
+	switch {
+	case report.ContextProfile == "":
+		fmt.Fprintln(w, "Context profile: unknown")
+	case report.ProfileMatches:
+		fmt.Fprintf(w, "Context profile: %s (matches AWS_PROFILE)\n", report.ContextProfile)
+	default:
+		fmt.Fprintf(w, "Context profile: %s (does NOT match AWS_PROFILE)\n", report.ContextProfile)
+	}
+}
+
+// getCallerIdentity calls STS GetCallerIdentity for the profile
+func getCallerIdentity(ctx context.Context, profile string) (string, string, error) {
+	cfg, err := loadAWSConfig(ctx, profile)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	out, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", "", err
+	}
+	return aws.ToString(out.Account), aws.ToString(out.Arn), nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+current-context: payments-eks-1
+clusters:
+- name: arn:aws:eks:eu-west-1:123456789012:cluster/payments-eks-1
+  cluster:
+    server: https://example.eks.amazonaws.com
+contexts:
+- name: payments-eks-1
+  context:
+    cluster: arn:aws:eks:eu-west-1:123456789012:cluster/payments-eks-1
+    user: arn:aws:eks:eu-west-1:123456789012:cluster/payments-eks-1
+users:
+- name: arn:aws:eks:eu-west-1:123456789012:cluster/payments-eks-1
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: aws
+      args: ["eks", "get-token", "--cluster-name", "payments-eks-1", "--region", "eu-west-1"]
+      env:
+      - name: AWS_PROFILE
+        value: payments-prod
+`
+
+func TestStatusCommandJSON(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("AWS_PROFILE", "payments-prod")
+
+	kubeConfigPath := filepath.Join(home, "kubeconfig")
+	os.WriteFile(kubeConfigPath, []byte(testKubeConfig), 0600)
+	t.Setenv("KUBECONFIG", kubeConfigPath)
+
+	os.MkdirAll(filepath.Join(home, ".aws"), 0755)
+	os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`[sso-session acme]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = eu-central-1
+
+[profile payments-prod]
+sso_session = acme
+sso_account_id = 123456789012
+sso_role_name = operator
+region = eu-west-1
+`), 0600)
+	expiresAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	writeSSOCacheFile(t, home, "acme.json", map[string]interface{}{
+		"startUrl":    "https://acme.awsapps.com/start",
+		"region":      "eu-central-1",
+		"accessToken": "token",
+		"expiresAt":   expiresAt.Format(time.RFC3339),
+	})
+
+	originalFetcher := callerIdentityFetcher
+	defer func() { callerIdentityFetcher = originalFetcher }()
+	callerIdentityFetcher = func(ctx context.Context, profile string) (string, string, error) {
+		return "123456789012", "arn:aws:sts::123456789012:assumed-role/operator/jane", nil
+	}
+
+	var output bytes.Buffer
+	rootCmd.SetOut(&output)
+	rootCmd.SetArgs([]string{"status", "--output", "json"})
+	defer func() { statusOutput = "text" }()
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var report statusReport
+	if err := json.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output, got %v:\n%s", err, output.String())
+	}
+	if report.Profile != "payments-prod" || report.AccountID != "123456789012" {
+		t.Errorf("Unexpected identity in report: %+v", report)
+	}
+	if report.SSOExpiresAt == nil || !report.SSOExpiresAt.Equal(expiresAt) || report.SSOExpired {
+		t.Errorf("Expected SSO token expiry %v, got %+v", expiresAt, report.SSOExpiresAt)
+	}
+	if report.Context != "payments-eks-1" || report.ClusterRegion != "eu-west-1" {
+		t.Errorf("Unexpected kube context in report: %+v", report)
+	}
+	if report.ContextProfile != "payments-prod" || !report.ProfileMatches {
+		t.Errorf("Expected context profile to match AWS_PROFILE, got %+v", report)
+	}
+
+	var text bytes.Buffer
+	printStatus(&text, report)
+	if !strings.Contains(text.String(), "(matches AWS_PROFILE)") {
+		t.Errorf("Expected text output to mention the matching profile, got:\n%s", text.String())
+	}
+}
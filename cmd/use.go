@@ -3,6 +3,7 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,9 +11,11 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/eimarfandino/asp-eks/awsutils"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
@@ -27,14 +30,27 @@ var clusterProvider ClusterProvider = &AWSClusterProvider{}
 // credentialsValidator can be mocked in tests
 var credentialsValidator = isCredentialsValid
 
-var exportFlag bool
+var (
+	exportFlag  bool
+	useAll      bool
+	useTags     []string
+	verifyFlag  bool
+	roleArnFlag string
+)
 
 var useCmd = &cobra.Command{
-	Use:   "use [profile]",
+	Use:   "use [profile[:cluster]|alias]",
 	Short: "Use a specific AWS profile and set kubeconfig for an EKS cluster",
-	Args:  cobra.ExactArgs(1),
+	Long: `Use a specific AWS profile and set kubeconfig for an EKS cluster.
+
+Give the cluster as <profile>:<cluster> to skip the cluster picker, or use an alias created with
+'asp-eks alias set'. Use "-" to switch back to the previous profile and cluster.
+
+The picker shows the status of each cluster and hides clusters that are not ACTIVE unless --all
+is given. --tag key=value only offers clusters with a matching EKS tag; the value may be a glob
+or re: pattern and a tag without value matches any value.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		profile := args[0]
 		ctx := context.Background()
 
 		// If export flag is set, redirect informational output to stderr
@@ -44,6 +60,28 @@ var useCmd = &cobra.Command{
 		}
 		defer func() { outputWriter = originalWriter }()
 
+		if args[0] == "-" {
+			previous, err := previousSwitch()
+			if err != nil {
+				fmt.Fprintln(outputWriter, "Cannot switch back:", err)
+				return
+			}
+			fmt.Fprintf(outputWriter, "Switching back to %s / %s\n", previous.Profile, previous.Cluster)
+			switchTo(*previous, exportFlag)
+			return
+		}
+
+		if err := validateTagFilters(useTags); err != nil {
+			fmt.Fprintln(outputWriter, "Invalid --tag:", err)
+			return
+		}
+		settings, err := loadSettings()
+		if err != nil {
+			fmt.Fprintln(outputWriter, "Error:", err)
+			return
+		}
+		profile, cluster := resolveTarget(loadAliases(settings), args[0])
+
 		// Try to login via SSO first
 		if err := ensureSSO(profile); err != nil {
 			fmt.Fprintf(outputWriter, "Failed to ensure SSO login: %v\n", err)
@@ -62,6 +100,11 @@ var useCmd = &cobra.Command{
 			return
 		}
 
+		if cluster != "" {
+			updateKubeconfig(profile, cluster, exportFlag)
+			return
+		}
+
 		// List clusters
 		clusterList, err := clusterProvider.ListClusters(ctx, profile)
 		if err != nil {
@@ -74,32 +117,26 @@ var useCmd = &cobra.Command{
 			return
 		}
 
+		clusterList, statuses, hidden := filterClusters(ctx, profile, clusterList)
+		if len(clusterList) == 0 {
+			fmt.Fprintf(outputWriter, "No matching clusters found, %d hidden by status or tag filters (use --all to show clusters that are not ACTIVE)\n", hidden)
+			return
+		}
+		if hidden > 0 {
+			fmt.Fprintf(outputWriter, "%d clusters hidden by status or tag filters\n", hidden)
+		}
+
 		if len(clusterList) == 1 {
 			fmt.Fprintln(outputWriter, "Only one cluster found:", clusterList[0])
 			updateKubeconfig(profile, clusterList[0], exportFlag)
 			return
 		}
 
-		fmt.Fprintln(outputWriter, "Available clusters in region", region)
-		for i, cluster := range clusterList {
-			fmt.Fprintf(outputWriter, "[%d] %s\n", i+1, cluster)
-		}
-
-		fmt.Fprint(outputWriter, "Select cluster by number: ")
-		reader := bufio.NewReader(os.Stdin)
-		input, err := reader.ReadString('\n')
+		selected, err := pickCluster(profile, region, clusterList, statuses)
 		if err != nil {
-			fmt.Fprintln(outputWriter, "Error reading input:", err)
-			return
-		}
-		input = strings.TrimSpace(input)
-		choice, err := strconv.Atoi(input)
-		if err != nil || choice < 1 || choice > len(clusterList) {
-			fmt.Fprintln(outputWriter, "Invalid selection")
+			fmt.Fprintln(outputWriter, err)
 			return
 		}
-
-		selected := clusterList[choice-1]
 		updateKubeconfig(profile, selected, exportFlag)
 	},
 }
@@ -107,6 +144,105 @@ var useCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(useCmd)
 	useCmd.Flags().BoolVar(&exportFlag, "export", false, "Output shell commands for eval (export AWS_PROFILE)")
+	useCmd.Flags().BoolVar(&useAll, "all", false, "Also offer clusters that are not ACTIVE")
+	useCmd.Flags().StringArrayVar(&useTags, "tag", nil, "Only offer clusters with this tag, as key=value or key (repeatable)")
+	useCmd.Flags().BoolVar(&verifyFlag, "verify", false, "Check the role's access to the cluster after switching")
+	useCmd.Flags().StringVar(&roleArnFlag, "role-arn", "", "Role kubectl assumes for the cluster token instead of the profile's role")
+}
+
+// filterClusters describes the clusters and keeps the ACTIVE ones (all with --all) that match the
+// --tag filters. It returns the clusters to offer, the status of each cluster and how many were
+// hidden. Clusters that can't be described are kept unless tags are filtered on.
+func filterClusters(ctx context.Context, profile string, clusterList []string) ([]string, map[string]string, int) {
+	details := make([]*ClusterInfo, len(clusterList))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, 8)
+	for i, cluster := range clusterList {
+		wg.Add(1)
+		go func(i int, cluster string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			details[i], _ = clusterProvider.GetClusterInfo(ctx, profile, cluster)
+		}(i, cluster)
+	}
+	wg.Wait()
+
+	var shown []string
+	statuses := make(map[string]string)
+	for i, cluster := range clusterList {
+		if details[i] == nil {
+			statuses[cluster] = "UNKNOWN"
+			if len(useTags) == 0 {
+				shown = append(shown, cluster)
+			}
+			continue
+		}
+		statuses[cluster] = details[i].Status
+		if (useAll || details[i].Status == "ACTIVE") && matchTags(useTags, details[i].Tags) {
+			shown = append(shown, cluster)
+		}
+	}
+	return shown, statuses, len(clusterList) - len(shown)
+}
+
+// matchTags reports whether the tags match every key=value filter. Values are matched as
+// patterns, a filter without value only requires the key.
+func matchTags(filters []string, tags map[string]string) bool {
+	for _, filter := range filters {
+		key, pattern, hasValue := strings.Cut(filter, "=")
+		value, ok := tags[key]
+		if !ok || (hasValue && !matchPattern(pattern, value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTagFilters checks the value patterns of key=value tag filters
+func validateTagFilters(filters []string) error {
+	for _, filter := range filters {
+		if _, pattern, hasValue := strings.Cut(filter, "="); hasValue {
+			if err := validatePatterns(pattern); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pickCluster asks on stdin which of the profile's clusters to use, favourites first. Statuses,
+// when known, are shown next to the clusters.
+func pickCluster(profile, region string, clusterList []string, statuses map[string]string) (string, error) {
+	_, favouriteClusters := loadFavouriteTargets()
+	clusterList, pinned := pinFavourites(clusterList, func(cluster string) bool {
+		return favouriteClusters[profile+":"+cluster]
+	})
+
+	fmt.Fprintln(outputWriter, "Available clusters in region", region)
+	for i, cluster := range clusterList {
+		label := cluster
+		if status := statuses[cluster]; status != "" {
+			label += " (" + status + ")"
+		}
+		if i < pinned {
+			label += " ★"
+		}
+		fmt.Fprintf(outputWriter, "[%d] %s\n", i+1, label)
+	}
+
+	fmt.Fprint(outputWriter, "Select cluster by number: ")
+	reader := bufio.NewReader(os.Stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("Error reading input: %w", err)
+	}
+	input = strings.TrimSpace(input)
+	choice, err := strconv.Atoi(input)
+	if err != nil || choice < 1 || choice > len(clusterList) {
+		return "", errors.New("Invalid selection")
+	}
+	return clusterList[choice-1], nil
 }
 
 func updateKubeconfig(profile, cluster string, export bool) {
@@ -120,15 +256,56 @@ func updateKubeconfig(profile, cluster string, export bool) {
 		fmt.Fprintf(outputWriter, "Failed to get cluster info: %v\n", err)
 		return
 	}
+	if clusterInfo, err = withRoleOverride(profile, clusterInfo, roleArnFlag); err != nil {
+		fmt.Fprintf(outputWriter, "Failed to apply role override: %v\n", err)
+		return
+	}
+	if roleArn := tokenRoleArn(clusterInfo); roleArn != "" {
+		fmt.Fprintf(outputWriter, "kubectl will authenticate as %s\n", roleArn)
+	}
 
-	err = createOrUpdateKubeContext(profile, clusterInfo)
+	// Protected clusters need a typed confirmation and may get a distinct context name
+	contextInfo := clusterInfo
+	settings, err := loadSettings()
+	if err != nil {
+		fmt.Fprintf(outputWriter, "Failed to load settings: %v\n", err)
+		return
+	}
+	rules, err := loadProtectionRules(settings)
+	if err != nil {
+		fmt.Fprintf(outputWriter, "Failed to load settings: %v\n", err)
+		return
+	}
+	if reason := rules.MatchesCluster(profile, clusterInfo); reason != "" {
+		printProtectedBanner(outputWriter, profile, clusterInfo, reason)
+		if err := confirmProtected(outputWriter, clusterInfo.Name); err != nil {
+			fmt.Fprintln(outputWriter, err)
+			return
+		}
+		if rules.ContextPrefix != "" && !strings.HasPrefix(clusterInfo.Name, rules.ContextPrefix) {
+			prefixed := *clusterInfo
+			prefixed.Name = rules.ContextPrefix + clusterInfo.Name
+			contextInfo = &prefixed
+		}
+	}
+
+	err = createOrUpdateKubeContext(profile, contextInfo)
 	if err != nil {
 		fmt.Fprintf(outputWriter, "Failed to update kubeconfig: %v\n", err)
 		return
 	}
 
 	fmt.Fprintf(outputWriter, "Successfully updated kubeconfig for cluster: %s\n", cluster)
-	fmt.Fprintf(outputWriter, "Current context set to: %s\n", cluster)
+	fmt.Fprintf(outputWriter, "Current context set to: %s\n", contextInfo.Name)
+
+	entry := historyEntry{Profile: profile, Cluster: cluster, Context: contextInfo.Name, Time: time.Now()}
+	if err := recordHistory(entry); err != nil {
+		fmt.Fprintf(outputWriter, "Warning: failed to record switch in history: %v\n", err)
+	}
+
+	if verifyFlag {
+		verifyClusterAccess(ctx, profile, clusterInfo)
+	}
 
 	// If export flag is set, output shell commands
 	if export {
@@ -149,6 +326,15 @@ func createOrUpdateKubeContext(profile string, clusterInfo *ClusterInfo) error {
 	if configPath == "" {
 		return fmt.Errorf("could not determine kubeconfig path")
 	}
+	return writeKubeContext(configPath, clusterInfo)
+}
+
+// writeKubeContext adds or updates the cluster, user and context of the cluster in the kubeconfig
+// file at configPath and makes it the current context
+func writeKubeContext(configPath string, clusterInfo *ClusterInfo) error {
+	if clusterInfo.Endpoint == "" || len(clusterInfo.CertificateData) == 0 {
+		return fmt.Errorf("cluster %s has no endpoint or certificate authority data yet (status %s)", clusterInfo.Name, clusterInfo.Status)
+	}
 
 	// Ensure .kube directory exists
 	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
@@ -222,19 +408,89 @@ func createOrUpdateKubeContext(profile string, clusterInfo *ClusterInfo) error {
 	return nil
 }
 
-// ensureSSO attempts to validate credentials and automatically runs sso login if they're invalid
+// ensureSSO attempts to validate credentials and automatically runs sso login if they're invalid.
+// Profiles assuming a role are followed through source_profile to the SSO profile at the root of
+// the chain, which is the one logged in. Static, credential_process and web identity credentials
+// can't be refreshed by asp-eks and only get validated.
 func ensureSSO(profile string) error {
 	fmt.Fprintf(outputWriter, "Checking credentials for profile %s...\n", profile)
 
 	ctx := context.Background()
 
+	chain, err := awsutils.GetProfileChain(profile)
+	if err != nil {
+		// Unknown profiles are left to 'aws sso login' to report
+		chain = []awsutils.ProfileLink{{Name: profile, Kind: awsutils.ProfileKindSSO}}
+	}
+	root := chain[len(chain)-1]
+
+	// Validating a role chain assumes its roles, which asks for the MFA code of MFA roles. Check the
+	// SSO login at the root first, so an expired login doesn't cost an MFA code before and after it.
+	loggedIn := false
+	if len(chain) > 1 && root.Kind == awsutils.ProfileKindSSO && !hasValidSSOToken(ctx, root.Name) {
+		fmt.Fprintf(outputWriter, "SSO login for profile '%s' is expired. Profile assumes a role via %s, attempting SSO login for '%s'...\n",
+			profile, profileChainString(chain), root.Name)
+		if err := runSSOLogin(root.Name); err != nil {
+			return err
+		}
+		loggedIn = true
+	}
+
 	if credentialsValidator(ctx, profile) {
-		fmt.Fprintln(outputWriter, "Credentials are valid")
+		if loggedIn {
+			fmt.Fprintln(outputWriter, "SSO login successful")
+		} else {
+			fmt.Fprintln(outputWriter, "Credentials are valid")
+		}
 		return nil
 	}
+	if loggedIn {
+		return fmt.Errorf("credentials still invalid after SSO login for profile %s", profile)
+	}
+
+	switch root.Kind {
+	case awsutils.ProfileKindSSO:
+	case awsutils.ProfileKindStatic:
+		return fmt.Errorf("static credentials of profile %s are invalid, update them in ~/.aws/credentials", root.Name)
+	case awsutils.ProfileKindProcess:
+		return fmt.Errorf("credential_process of profile %s failed or returned invalid credentials", root.Name)
+	default:
+		return fmt.Errorf("credentials of profile %s (%s) are invalid and can't be refreshed by asp-eks", root.Name, root.Kind)
+	}
+
+	loginProfile := root.Name
+	if loginProfile != profile {
+		fmt.Fprintf(outputWriter, "Credentials for profile '%s' are expired or invalid. Profile assumes a role via %s, attempting SSO login for '%s'...\n",
+			profile, profileChainString(chain), loginProfile)
+	} else {
+		fmt.Fprintf(outputWriter, "Credentials for profile '%s' are expired or invalid. Attempting SSO login...\n", profile)
+	}
+	if err := runSSOLogin(loginProfile); err != nil {
+		return err
+	}
+
+	// Validate again after login
+	if !credentialsValidator(ctx, profile) {
+		return fmt.Errorf("credentials still invalid after SSO login for profile %s", profile)
+	}
+
+	fmt.Fprintln(outputWriter, "SSO login successful")
+	return nil
+}
 
-	fmt.Fprintf(outputWriter, "Credentials for profile '%s' are expired or invalid. Attempting SSO login...\n", profile)
+// hasValidSSOToken reports whether the SSO cache holds a valid, or refreshable, token for the
+// start URL of the profile
+func hasValidSSOToken(ctx context.Context, profile string) bool {
+	startURL, region, _, err := awsutils.GetProfileSSOSettings(profile)
+	if err != nil || startURL == "" {
+		return false
+	}
+	_, err = getSSOAccessToken(ctx, startURL, region)
+	return err == nil
+}
 
+// runSSOLogin runs 'aws sso login' for the profile, interactively
+func runSSOLogin(profile string) error {
 	cmd := execCommand("aws", "sso", "login", "--profile", profile)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = outputWriter
@@ -242,19 +498,24 @@ func ensureSSO(profile string) error {
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("SSO login failed for profile %s: %w", profile, err)
 	}
+	return nil
+}
 
-	// Validate again after login
-	if !credentialsValidator(ctx, profile) {
-		return fmt.Errorf("credentials still invalid after SSO login for profile %s", profile)
+// profileChainString shows a role chain as "a -> b -> c", marking roles that require MFA
+func profileChainString(chain []awsutils.ProfileLink) string {
+	names := make([]string, len(chain))
+	for i, link := range chain {
+		names[i] = link.Name
+		if link.MFASerial != "" {
+			names[i] += " (MFA)"
+		}
 	}
-
-	fmt.Fprintln(outputWriter, "SSO login successful")
-	return nil
+	return strings.Join(names, " -> ")
 }
 
 // isCredentialsValid checks if the current credentials are valid using AWS SDK
 func isCredentialsValid(ctx context.Context, profile string) bool {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(profile))
+	cfg, err := loadAWSConfig(ctx, profile)
 	if err != nil {
 		return false
 	}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -59,9 +60,23 @@ func (m *mockClusterProvider) GetClusterInfo(ctx context.Context, profile, clust
 		AuthEnv: map[string]string{
 			"AWS_PROFILE": profile,
 		},
+		Status: "ACTIVE",
 	}, nil
 }
 
+// describedClusterProvider returns the given cluster info, and an error for other clusters
+type describedClusterProvider struct {
+	mockClusterProvider
+	clusters map[string]*ClusterInfo
+}
+
+func (p *describedClusterProvider) GetClusterInfo(ctx context.Context, profile, clusterName string) (*ClusterInfo, error) {
+	if info, ok := p.clusters[clusterName]; ok {
+		return info, nil
+	}
+	return nil, fmt.Errorf("access denied")
+}
+
 func fakeExecCommand(command string, args ...string) *exec.Cmd {
 	cs := []string{"-test.run=TestHelperProcess", "--", command}
 	cs = append(cs, args...)
@@ -82,12 +97,30 @@ func TestHelperProcess(t *testing.T) {
 		fmt.Fprint(os.Stdout, "kubeconfig updated")
 	case strings.Contains(cmdStr, "kubectl"):
 		fmt.Fprint(os.Stdout, "kubectl command executed")
+	case strings.Contains(cmdStr, " print-env"):
+		kubeConfig, _ := os.ReadFile(os.Getenv("KUBECONFIG"))
+		fmt.Fprintf(os.Stdout, "AWS_PROFILE=%s AWS_REGION=%s\n%s", os.Getenv("AWS_PROFILE"), os.Getenv("AWS_REGION"), kubeConfig)
+	case strings.Contains(cmdStr, "print-shell-env"):
+		fmt.Fprintf(os.Stdout, "ASP_EKS_PROFILE=%s ASP_EKS_CONTEXT=%s KUBECONFIG=%s\n",
+			os.Getenv("ASP_EKS_PROFILE"), os.Getenv("ASP_EKS_CONTEXT"), os.Getenv("KUBECONFIG"))
+	case strings.Contains(cmdStr, "cluster-check"):
+		kubeConfig, _ := os.ReadFile(os.Getenv("KUBECONFIG"))
+		if strings.Contains(string(kubeConfig), "current-context: failing-eks") {
+			fmt.Fprintln(os.Stderr, "check failed")
+			os.Exit(3)
+		}
+		fmt.Fprintln(os.Stdout, "check passed")
+	case strings.Contains(cmdStr, "exit-3"):
+		os.Exit(3)
 	}
 
 	os.Exit(0)
 }
 
 func TestUseCommand_MockAWS(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ASP_EKS_HOME", "")
+
 	// Mock ClusterProvider
 	originalProvider := clusterProvider
 	clusterProvider = &mockClusterProvider{
@@ -132,6 +165,9 @@ func TestUseCommand_MockAWS(t *testing.T) {
 	if !strings.Contains(outStr, "Available clusters in region eu-west-1") {
 		t.Errorf("Expected region info, got: %s", outStr)
 	}
+	if !strings.Contains(outStr, "[1] cluster-one (ACTIVE)") {
+		t.Errorf("Expected cluster status in the picker, got: %s", outStr)
+	}
 	if !strings.Contains(outStr, "Updating kubeconfig for cluster: cluster-one") {
 		t.Errorf("Expected kubeconfig update message, got: %s", outStr)
 	}
@@ -139,3 +175,51 @@ func TestUseCommand_MockAWS(t *testing.T) {
 		t.Errorf("Expected kubeconfig update confirmation, got: %s", outStr)
 	}
 }
+
+func TestFilterClusters(t *testing.T) {
+	originalProvider := clusterProvider
+	defer func() { clusterProvider = originalProvider }()
+	clusterProvider = &describedClusterProvider{clusters: map[string]*ClusterInfo{
+		"payments-eks-1": {Status: "ACTIVE", Tags: map[string]string{"env": "prod", "team": "payments"}},
+		"preview-123":    {Status: "ACTIVE", Tags: map[string]string{"env": "preview"}},
+		"preview-124":    {Status: "CREATING", Tags: map[string]string{"env": "preview"}},
+		"old-eks":        {Status: "DELETING"},
+	}}
+	clusters := []string{"payments-eks-1", "preview-123", "preview-124", "old-eks", "restricted"}
+	defer func() { useAll, useTags = false, nil }()
+
+	tests := []struct {
+		all    bool
+		tags   []string
+		want   []string
+		hidden int
+	}{
+		{false, nil, []string{"payments-eks-1", "preview-123", "restricted"}, 2},
+		{true, nil, clusters, 0},
+		{false, []string{"env=prod"}, []string{"payments-eks-1"}, 4},
+		{true, []string{"env=prev*"}, []string{"preview-123", "preview-124"}, 3},
+		{false, []string{"team"}, []string{"payments-eks-1"}, 4},
+		{false, []string{"env=prod", "team=platform"}, nil, 5},
+	}
+	for _, tt := range tests {
+		useAll, useTags = tt.all, tt.tags
+		shown, statuses, hidden := filterClusters(context.Background(), "p", clusters)
+		if strings.Join(shown, ",") != strings.Join(tt.want, ",") || hidden != tt.hidden {
+			t.Errorf("all=%v tags=%v: got %v (%d hidden), want %v (%d hidden)", tt.all, tt.tags, shown, hidden, tt.want, tt.hidden)
+		}
+		if statuses["preview-124"] != "CREATING" || statuses["restricted"] != "UNKNOWN" {
+			t.Errorf("Unexpected statuses %v", statuses)
+		}
+	}
+}
+
+func TestWriteKubeContextRequiresEndpoint(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	err := writeKubeContext(configPath, &ClusterInfo{Name: "preview-124", Arn: "arn:aws:eks:eu-west-1:123456789012:cluster/preview-124", Status: "CREATING"})
+	if err == nil || !strings.Contains(err.Error(), "status CREATING") {
+		t.Errorf("Expected an error for a cluster without endpoint, got %v", err)
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no kubeconfig to be written, got %v", err)
+	}
+}